@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Xabarni olgan xodim yozuvi (qayta tayinlash tarixi uchun)
+type Assignment struct {
+	UserID int64     `json:"user_id"` // 0 - mas'ul olib tashlangan
+	Name   string    `json:"name"`
+	By     int64     `json:"by"`
+	ByName string    `json:"by_name"`
+	At     time.Time `json:"at"`
+}
+
+// Xabarga mas'ul tayinlash va tarixga yozish. userID 0 bo'lsa mas'ul olib tashlanadi
+func assignPending(key PendingKey, userID int64, name string, by *tgbotapi.User) (*PendingMessage, *Assignment, bool) {
+	var previous *Assignment
+	msg, exists := state.UpdatePending(key, func(msg *PendingMessage) bool {
+		if !msg.isOpen() || msg.AssigneeID == userID {
+			return false
+		}
+		if msg.AssigneeID != 0 {
+			previous = &Assignment{UserID: msg.AssigneeID, Name: msg.AssigneeName}
+		}
+		msg.AssigneeID = userID
+		msg.AssigneeName = name
+		msg.Assignments = append(msg.Assignments, Assignment{UserID: userID, Name: name, By: by.ID, ByName: displayName(by), At: time.Now()})
+		return true
+	})
+	return msg, previous, exists
+}
+
+// "🙋 Men olaman" tugmasi
+func handleClaimCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if !canUseCardActions(callback.From) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Kuzatuvchi bu amalni bajara olmaydi"))
+		return
+	}
+	key, ok := parseMarkAnsweredData(payload)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+
+	name := displayName(callback.From)
+	if member, ok := staff.Get(callback.From.ID); ok {
+		name = member.Name
+	}
+	msg, previous, exists := assignPending(key, callback.From.ID, name, callback.From)
+	if !exists || !msg.isOpen() {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar allaqachon yopilgan"))
+		return
+	}
+	if msg.AssigneeID != callback.From.ID {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+
+	logAssignment(key, msg, previous)
+	refreshReminderCards(msg)
+	bot.Request(tgbotapi.NewCallback(callback.ID, "🙋 Xabar sizga biriktirildi"))
+}
+
+// /assign <id> <@username|user_id|-> - mas'ul tayinlash ("-" - olib tashlash)
+func handleAssignCommand(message *tgbotapi.Message) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		replyText(message, "❗ Foydalanish: /assign <GURUH:XABAR yoki havola> <@username|user_id|->")
+		return
+	}
+	key, ok := parseTicketRef(message)
+	if !ok {
+		replyText(message, "❗ Xabar identifikatori noto'g'ri")
+		return
+	}
+
+	var assignee StaffMember
+	if args[1] != "-" {
+		member, ok := findStaffRef(args[1])
+		if !ok {
+			replyText(message, fmt.Sprintf("❓ %s xodimlar ro'yxatida yo'q (/staff list)", args[1]))
+			return
+		}
+		if !member.Role.canAnswer() {
+			replyText(message, fmt.Sprintf("⛔ %s kuzatuvchi - xabar biriktirib bo'lmaydi", member.Name))
+			return
+		}
+		assignee = member
+	}
+
+	msg, previous, exists := assignPending(key, assignee.UserID, assignee.Name, message.From)
+	if !exists {
+		replyText(message, fmt.Sprintf("❓ %s xabari topilmadi", key))
+		return
+	}
+	if !msg.isOpen() {
+		replyText(message, fmt.Sprintf("ℹ️ %s allaqachon yopilgan (%s)", key, msg.Status))
+		return
+	}
+
+	logAssignment(key, msg, previous)
+	refreshReminderCards(msg)
+	if assignee.UserID == 0 {
+		replyText(message, fmt.Sprintf("✅ %s - mas'ul olib tashlandi", key))
+		return
+	}
+	if cfg.AssigneeDM {
+		notifyAssignee(msg)
+	}
+	replyText(message, fmt.Sprintf("✅ %s → %s", key, assignee.Name))
+}
+
+// Xodimni @username yoki ID bo'yicha registrdan topish
+func findStaffRef(ref string) (StaffMember, bool) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return staff.Get(id)
+	}
+	return staff.FindByUsername(strings.TrimPrefix(ref, "@"))
+}
+
+func logAssignment(key PendingKey, msg *PendingMessage, previous *Assignment) {
+	switch {
+	case msg.AssigneeID == 0:
+		log.Printf("🙋 %s: mas'ul olib tashlandi", key)
+	case previous != nil:
+		log.Printf("🙋 %s qayta biriktirildi: %s → %s", key, previous.Name, msg.AssigneeName)
+	default:
+		log.Printf("🙋 %s biriktirildi: %s", key, msg.AssigneeName)
+	}
+}
+
+// Mas'ulga shaxsiy xabar (assignee_dm yoqilgan bo'lsa). Xodim botga
+// /start bosmagan bo'lsa Telegram ruxsat bermaydi - xato faqat logga yoziladi
+func notifyAssignee(msg *PendingMessage) {
+	if msg.AssigneeID == 0 {
+		return
+	}
+	text := fmt.Sprintf(`🙋 Sizga biriktirilgan xabar javob kutmoqda
+
+🏢 Guruh: %s
+👤 Foydalanuvchi: @%s
+%s
+⏱️ %s dan beri`,
+		html.EscapeString(msg.GroupTitle),
+		html.EscapeString(msg.Username),
+		messageContentHTML(msg),
+		formatDuration(time.Since(msg.Timestamp)))
+
+	dm := tgbotapi.NewMessage(msg.AssigneeID, text)
+	dm.ParseMode = tgbotapi.ModeHTML
+	dm.DisableWebPagePreview = true
+	dm.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("📄 Xabarni ko'rish", messageLink(msg)),
+		),
+	)
+	if _, err := bot.Send(dm); err != nil {
+		log.Printf("⚠️ Mas'ulga shaxsiy xabar yuborib bo'lmadi (ID: %d): %v", msg.AssigneeID, err)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ish vaqti sozlamasi (config fayldagi "working_hours")
+type WorkingHoursConfig struct {
+	Timezone string            `json:"timezone"` // Masalan "Asia/Tashkent"
+	Schedule map[string]string `json:"schedule"` // "mon": "09:00-18:00" yoki "09:00-13:00,14:00-18:00", bo'sh - dam olish
+	Holidays []string          `json:"holidays"` // "2026-03-21" (bir martalik) yoki "09-01" (har yili)
+}
+
+// Kun ichidagi ish oralig'i (daqiqalarda, yarim tundan boshlab)
+type workPeriod struct {
+	start, end int
+}
+
+// Ish kalendari: haftalik jadval, vaqt zonasi va bayramlar
+type Calendar struct {
+	loc      *time.Location
+	days     [7][]workPeriod // time.Weekday bo'yicha
+	holidays map[string]bool // "2006-01-02" yoki "01-02"
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Sozlamadan kalendar yaratish
+func newCalendar(c *WorkingHoursConfig) (*Calendar, error) {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("working_hours.timezone noto'g'ri (%q): %w", c.Timezone, err)
+	}
+	cal := &Calendar{loc: loc, holidays: make(map[string]bool)}
+
+	open := false
+	for name, spec := range c.Schedule {
+		day, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("working_hours.schedule: noma'lum kun %q (mon, tue, ... sun)", name)
+		}
+		periods, err := parseWorkPeriods(spec)
+		if err != nil {
+			return nil, fmt.Errorf("working_hours.schedule.%s: %w", name, err)
+		}
+		cal.days[day] = periods
+		open = open || len(periods) > 0
+	}
+	if !open {
+		return nil, fmt.Errorf("working_hours.schedule da kamida bitta ish kuni bo'lishi kerak")
+	}
+
+	for _, h := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err == nil {
+			cal.holidays[h] = true
+			continue
+		}
+		if _, err := time.Parse("01-02", h); err == nil {
+			cal.holidays[h] = true
+			continue
+		}
+		return nil, fmt.Errorf("working_hours.holidays: sana noto'g'ri %q (YYYY-MM-DD yoki MM-DD)", h)
+	}
+	return cal, nil
+}
+
+// "09:00-13:00,14:00-18:00" ni parse qilish
+func parseWorkPeriods(spec string) ([]workPeriod, error) {
+	var periods []workPeriod
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("oraliq noto'g'ri %q (masalan 09:00-18:00)", part)
+		}
+		start, err := parseClock(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("oraliq oxiri boshidan keyin bo'lishi kerak: %q", part)
+		}
+		periods = append(periods, workPeriod{start: start, end: end})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start < periods[j].start })
+	for i := 1; i < len(periods); i++ {
+		if periods[i].start < periods[i-1].end {
+			return nil, fmt.Errorf("oraliqlar ustma-ust tushadi: %q", spec)
+		}
+	}
+	return periods, nil
+}
+
+// "09:30" -> 570 daqiqa. "24:00" kun oxiri sifatida ruxsat etiladi
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("vaqt noto'g'ri %q (HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Kun bayrammi
+func (c *Calendar) isHoliday(day time.Time) bool {
+	return c.holidays[day.Format("2006-01-02")] || c.holidays[day.Format("01-02")]
+}
+
+// Kunning ish oraliqlari mutlaq vaqtlarda
+func (c *Calendar) periodsOn(day time.Time) [][2]time.Time {
+	if c.isHoliday(day) {
+		return nil
+	}
+	y, m, d := day.Date()
+	var result [][2]time.Time
+	for _, p := range c.days[day.Weekday()] {
+		start := time.Date(y, m, d, p.start/60, p.start%60, 0, 0, c.loc)
+		end := time.Date(y, m, d, 0, 0, 0, 0, c.loc).Add(time.Duration(p.end) * time.Minute)
+		result = append(result, [2]time.Time{start, end})
+	}
+	return result
+}
+
+// Ofis hozir ochiqmi
+func (c *Calendar) IsOpen(t time.Time) bool {
+	t = t.In(c.loc)
+	for _, p := range c.periodsOn(t) {
+		if !t.Before(p[0]) && t.Before(p[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kun boshi (kalendar vaqt zonasida)
+func (c *Calendar) dayStart(t time.Time) time.Time {
+	y, m, d := t.In(c.loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+}
+
+// Ikki sana orasidagi kunlar soni (vaqt zonasi va yozgi vaqtdan qat'iy nazar)
+func civilDays(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return int((time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Unix() - time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC).Unix()) / 86400)
+}
+
+// Hafta kunining jadvaldagi ish vaqti (bayramlar hisobga olinmaydi)
+func (c *Calendar) weekdayDuration(day time.Weekday) time.Duration {
+	var total time.Duration
+	for _, p := range c.days[day] {
+		total += time.Duration(p.end-p.start) * time.Minute
+	}
+	return total
+}
+
+// To'liq haftaning ish vaqti
+func (c *Calendar) weekDuration() time.Duration {
+	var total time.Duration
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		total += c.weekdayDuration(day)
+	}
+	return total
+}
+
+// Bitta kunning from-to oralig'iga tushadigan ish vaqti
+func (c *Calendar) workOnDay(day, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, p := range c.periodsOn(day) {
+		start, end := p[0], p[1]
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// [start, end) kunlari orasidagi bayramlarga to'g'ri keladigan ish vaqti
+func (c *Calendar) holidayWork(start, end time.Time) time.Duration {
+	seen := make(map[string]bool)
+	var total time.Duration
+	add := func(day time.Time) {
+		key := day.Format("2006-01-02")
+		if seen[key] || day.Before(start) || !day.Before(end) {
+			return
+		}
+		seen[key] = true
+		total += c.weekdayDuration(day.Weekday())
+	}
+
+	for h := range c.holidays {
+		if day, err := time.ParseInLocation("2006-01-02", h, c.loc); err == nil {
+			add(day)
+			continue
+		}
+		md, err := time.Parse("01-02", h)
+		if err != nil {
+			continue
+		}
+		for y := start.Year(); y <= end.Year(); y++ {
+			// 29-fevral kabiso bo'lmagan yilda mavjud emas
+			if day := time.Date(y, md.Month(), md.Day(), 0, 0, 0, 0, c.loc); day.Month() == md.Month() {
+				add(day)
+			}
+		}
+	}
+	return total
+}
+
+// from va to orasidagi ish vaqti. Chetdagi kunlar aniq hisoblanadi,
+// orasidagi to'liq haftalar esa kunma-kun yurmasdan arifmetik qo'shiladi
+func (c *Calendar) WorkingDuration(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	from, to = from.In(c.loc), to.In(c.loc)
+	first, last := c.dayStart(from), c.dayStart(to)
+
+	total := c.workOnDay(first, from, to)
+	if !last.After(first) {
+		return total
+	}
+	total += c.workOnDay(last, from, to)
+
+	day := first.AddDate(0, 0, 1)
+	if weeks := civilDays(day, last) / 7; weeks > 0 {
+		end := day.AddDate(0, 0, weeks*7)
+		total += time.Duration(weeks)*c.weekDuration() - c.holidayWork(day, end)
+		day = end
+	}
+	for ; day.Before(last); day = day.AddDate(0, 0, 1) {
+		total += c.workOnDay(day, from, to)
+	}
+	return total
+}
+
+// Ofis keyingi ochiladigan vaqt (ochiq bo'lsa t ning o'zi). Bir yil ichida
+// ish kuni topilmasa nol vaqt qaytariladi
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	t = t.In(c.loc)
+	y, m, d := t.Date()
+	for day, i := time.Date(y, m, d, 0, 0, 0, 0, c.loc), 0; i < 366; day, i = day.AddDate(0, 0, 1), i+1 {
+		for _, p := range c.periodsOn(day) {
+			if t.Before(p[1]) {
+				if t.After(p[0]) {
+					return t
+				}
+				return p[0]
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Ofis oxirgi yopilgan vaqt: t dan oldingi (yoki t ga teng) eng so'nggi ish
+// oralig'i oxiri. Bir yil ichida ish kuni topilmasa nol vaqt qaytariladi
+func (c *Calendar) LastClose(t time.Time) time.Time {
+	t = t.In(c.loc)
+	for day, i := c.dayStart(t), 0; i < 366; day, i = day.AddDate(0, 0, -1), i+1 {
+		var last time.Time
+		for _, p := range c.periodsOn(day) {
+			if !p[1].After(t) && p[1].After(last) {
+				last = p[1]
+			}
+		}
+		if !last.IsZero() {
+			return last
+		}
+	}
+	return time.Time{}
+}
+
+// Global ish kalendari. nil - kalendar sozlanmagan, vaqt 24/7 hisoblanadi
+var workCalendar *Calendar
+
+// Ish vaqti hisobida from dan to gacha o'tgan vaqt
+func workingElapsed(from, to time.Time) time.Duration {
+	if workCalendar == nil {
+		return to.Sub(from)
+	}
+	return workCalendar.WorkingDuration(from, to)
+}
+
+// Ofis ochiqmi (kalendar bo'lmasa har doim ochiq)
+func officeOpen(t time.Time) bool {
+	return workCalendar == nil || workCalendar.IsOpen(t)
+}
+
+// Joriy yopiq davr boshlangan vaqt (kalendar bo'lmasa nol vaqt)
+func closedSince(t time.Time) time.Time {
+	if workCalendar == nil {
+		return time.Time{}
+	}
+	return workCalendar.LastClose(t)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func testCalendar(t *testing.T) *Calendar {
+	t.Helper()
+	cal, err := newCalendar(&WorkingHoursConfig{
+		Timezone: "Asia/Tashkent",
+		Schedule: map[string]string{
+			"mon": "09:00-13:00,14:00-18:00",
+			"tue": "09:00-18:00",
+			"wed": "09:00-18:00",
+			"thu": "09:00-18:00",
+			"fri": "09:00-18:00",
+			"sat": "10:00-15:00",
+		},
+		Holidays: []string{"2026-03-20", "03-21", "02-29"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+// Kunma-kun hisoblaydigan oddiy variant - arifmetik hisob bilan solishtirish uchun
+func naiveWorkingDuration(c *Calendar, from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	var total time.Duration
+	for day := c.dayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		total += c.workOnDay(day, from.In(c.loc), to.In(c.loc))
+	}
+	return total
+}
+
+func TestCalendarIsOpen(t *testing.T) {
+	cal := testCalendar(t)
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, cal.loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name string
+		at   string
+		want bool
+	}{
+		{"dushanba ertalab", "2026-10-19 09:00", true},
+		{"tushlik", "2026-10-19 13:30", false},
+		{"tushlikdan keyin", "2026-10-19 14:00", true},
+		{"kun oxiri", "2026-10-19 18:00", false},
+		{"shanba", "2026-10-24 12:00", true},
+		{"yakshanba", "2026-10-25 12:00", false},
+		{"bir martalik bayram", "2026-03-20 10:00", false},
+		{"har yilgi bayram", "2028-03-21 10:00", false},
+		{"bayramdan keyingi kun", "2028-03-22 10:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.IsOpen(at(tt.at)); got != tt.want {
+				t.Errorf("IsOpen(%s) = %v, kutilgan %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarWorkingDuration(t *testing.T) {
+	cal := testCalendar(t)
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, cal.loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     time.Duration
+	}{
+		{"bir kun ichida", "2026-10-20 10:00", "2026-10-20 11:30", 90 * time.Minute},
+		{"tushlik hisoblanmaydi", "2026-10-19 12:00", "2026-10-19 15:00", 2 * time.Hour},
+		{"kechqurundan ertalabgacha", "2026-10-20 17:30", "2026-10-21 09:30", time.Hour},
+		{"dam olish kuni", "2026-10-24 14:00", "2026-10-26 10:00", 2 * time.Hour},
+		{"to'liq hafta", "2026-10-19 00:00", "2026-10-26 00:00", 49 * time.Hour},
+		{"bayram haftasi", "2026-03-16 00:00", "2026-03-23 00:00", 49*time.Hour - 9*time.Hour - 5*time.Hour},
+		{"teskari oraliq", "2026-10-20 11:00", "2026-10-20 10:00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.WorkingDuration(at(tt.from), at(tt.to)); got != tt.want {
+				t.Errorf("WorkingDuration = %v, kutilgan %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarWorkingDurationMatchesDayByDay(t *testing.T) {
+	cal := testCalendar(t)
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, cal.loc)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		from := base.Add(time.Duration(rnd.Int63n(int64(3 * 365 * 24 * time.Hour))))
+		to := from.Add(time.Duration(rnd.Int63n(int64(200 * 24 * time.Hour))))
+		if got, want := cal.WorkingDuration(from, to), naiveWorkingDuration(cal, from, to); got != want {
+			t.Fatalf("%s - %s: %v, kunma-kun %v", from, to, got, want)
+		}
+	}
+}
+
+// Nol vaqtdan hisoblash (LastReminder bo'sh bo'lgan eski yozuvlar) ham to'g'ri
+// bo'lishi kerak: oraliqlarga bo'lib hisoblangan yig'indi bilan bir xil
+func TestCalendarWorkingDurationFromZeroTime(t *testing.T) {
+	cal := testCalendar(t)
+	mid := time.Date(2025, 10, 20, 12, 0, 0, 0, cal.loc)
+	to := time.Date(2026, 10, 19, 11, 30, 0, 0, cal.loc)
+
+	total := cal.WorkingDuration(time.Time{}, to)
+	head := cal.WorkingDuration(time.Time{}, mid)
+	tail := naiveWorkingDuration(cal, mid, to)
+	if head <= 0 || tail <= 0 {
+		t.Fatalf("bo'laklar musbat bo'lishi kerak: %v, %v", head, tail)
+	}
+	if total != head+tail {
+		t.Fatalf("WorkingDuration(0, to) = %v, bo'laklar yig'indisi %v + %v = %v", total, head, tail, head+tail)
+	}
+}
+
+func BenchmarkCalendarWorkingDurationFromZeroTime(b *testing.B) {
+	cal, err := newCalendar(&WorkingHoursConfig{
+		Timezone: "Asia/Tashkent",
+		Schedule: map[string]string{"mon": "09:00-18:00", "tue": "09:00-18:00", "wed": "09:00-18:00", "thu": "09:00-18:00", "fri": "09:00-18:00"},
+		Holidays: []string{"01-01", "03-21"},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	to := time.Date(2026, 10, 19, 11, 30, 0, 0, cal.loc)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cal.WorkingDuration(time.Time{}, to)
+	}
+}
+
+func TestCalendarNextOpen(t *testing.T) {
+	cal := testCalendar(t)
+	// Juma kechqurun -> shanba 10:00
+	from := time.Date(2026, 10, 23, 19, 0, 0, 0, cal.loc)
+	want := time.Date(2026, 10, 24, 10, 0, 0, 0, cal.loc)
+	if got := cal.NextOpen(from); !got.Equal(want) {
+		t.Errorf("NextOpen = %v, kutilgan %v", got, want)
+	}
+	// Ochiq paytda - o'sha vaqtning o'zi
+	open := time.Date(2026, 10, 20, 11, 0, 0, 0, cal.loc)
+	if got := cal.NextOpen(open); !got.Equal(open) {
+		t.Errorf("NextOpen = %v, kutilgan %v", got, open)
+	}
+}
+
+func TestCalendarLastClose(t *testing.T) {
+	cal := testCalendar(t)
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"dushanba kechqurun", time.Date(2026, 10, 19, 20, 0, 0, 0, cal.loc), time.Date(2026, 10, 19, 18, 0, 0, 0, cal.loc)},
+		{"tushlik", time.Date(2026, 10, 19, 13, 30, 0, 0, cal.loc), time.Date(2026, 10, 19, 13, 0, 0, 0, cal.loc)},
+		{"dushanba tongi - shanba kechqurundan", time.Date(2026, 10, 19, 8, 0, 0, 0, cal.loc), time.Date(2026, 10, 17, 15, 0, 0, 0, cal.loc)},
+	}
+	for _, tt := range tests {
+		if got := cal.LastClose(tt.at); !got.Equal(tt.want) {
+			t.Errorf("%s: LastClose = %v, kutilgan %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Kechiktirish variantlari: callback dagi nom -> tugma matni
+var snoozeOptions = []struct {
+	name  string
+	label string
+}{
+	{"30m", "⏰ 30 daq"},
+	{"2h", "⏰ 2 soat"},
+	{"tomorrow", "⏰ Ertaga"},
+}
+
+// Ertalabki vaqt - ish kalendari sozlanmagan bo'lsa "ertaga" shu soatdan boshlanadi
+const snoozeMorningHour = 9
+
+// Kechiktirish muddati. "tomorrow" - ertangi kunning birinchi ish vaqti
+func snoozeDeadline(option string, now time.Time) (time.Time, bool) {
+	switch option {
+	case "30m":
+		return now.Add(30 * time.Minute), true
+	case "2h":
+		return now.Add(2 * time.Hour), true
+	case "tomorrow":
+		loc := now.Location()
+		if workCalendar != nil {
+			loc = workCalendar.loc
+		}
+		y, m, d := now.In(loc).Date()
+		tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		if workCalendar != nil {
+			if open := workCalendar.NextOpen(tomorrow); !open.IsZero() {
+				return open, true
+			}
+		}
+		return tomorrow.Add(snoozeMorningHour * time.Hour), true
+	}
+	return time.Time{}, false
+}
+
+// Karta tugmalari bilan ishlash huquqi: registrdagi kuzatuvchilardan boshqa hamma
+func canUseCardActions(user *tgbotapi.User) bool {
+	member, registered := staff.Get(user.ID)
+	return !registered || member.Role.canAnswer()
+}
+
+// "✅ Javob berildi" tugmasi
+func handleMarkAnsweredCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if !canUseCardActions(callback.From) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Kuzatuvchi bu amalni bajara olmaydi"))
+		return
+	}
+	key, ok := parseMarkAnsweredData(payload)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+
+	pendingMsg, ok := state.MarkAnswered(key, callback.From.ID, displayName(callback.From))
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar allaqachon yopilgan"))
+		return
+	}
+	log.Printf("✅ Admin tomonidan javob berildi deb belgilandi: %s xabar", key)
+
+	// Eslatma kartalarini "javob berildi" holatiga o'tkazish
+	resolveSentMessages(pendingMsg)
+	bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "✅ Xabar javob berildi deb belgilandi!"))
+}
+
+// "🙈 Javob shart emas" tugmasi
+func handleIgnoreCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if !canUseCardActions(callback.From) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Kuzatuvchi bu amalni bajara olmaydi"))
+		return
+	}
+	key, ok := parseMarkAnsweredData(payload)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+	if msg, exists := state.GetPending(key); !exists || !msg.isOpen() {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar allaqachon yopilgan"))
+		return
+	}
+
+	closed, ok := state.CloseTicket(key, "ignored", callback.From.ID, displayName(callback.From))
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+	log.Printf("🙈 %s javob shart emas deb belgilandi (%s)", key, displayName(callback.From))
+	resolveSentMessages(closed)
+	bot.Request(tgbotapi.NewCallback(callback.ID, "🙈 Javob shart emas deb belgilandi"))
+}
+
+// "⏰ Kechiktirish" tugmalari: snooze_<variant>_<GURUH>_<XABAR>
+func handleSnoozeCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if !canUseCardActions(callback.From) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Kuzatuvchi bu amalni bajara olmaydi"))
+		return
+	}
+	sep := strings.Index(payload, "_")
+	if sep < 0 {
+		return
+	}
+	now := time.Now()
+	until, ok := snoozeDeadline(payload[:sep], now)
+	key, keyOK := parseMarkAnsweredData(payload[sep+1:])
+	if !ok || !keyOK {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Noto'g'ri tugma"))
+		return
+	}
+
+	snoozed, exists := state.UpdatePending(key, func(msg *PendingMessage) bool {
+		if !msg.isOpen() {
+			return false
+		}
+		msg.SnoozedUntil = until
+		msg.SnoozedBy = displayName(callback.From)
+		msg.CardUpdatedAt = now
+		return true
+	})
+	if !exists || !snoozed.isOpen() {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar allaqachon yopilgan"))
+		return
+	}
+
+	log.Printf("⏰ %s %s gacha kechiktirildi (%s)", key, until.Format("02.01.2006 15:04"), snoozed.SnoozedBy)
+	refreshReminderCards(snoozed)
+	bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("⏰ %s gacha kechiktirildi", until.Format("02.01 15:04"))))
+}
+
+// Xabar hozir kechiktirilganmi
+func (m *PendingMessage) snoozed(now time.Time) bool {
+	return now.Before(m.SnoozedUntil)
+}
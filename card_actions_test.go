@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestMarkAnsweredCallbackRequiresCardActions(t *testing.T) {
+	const (
+		viewerID     = 501
+		consultantID = 502
+	)
+	key := PendingKey{GroupID: -100, MessageID: 7}
+
+	tests := []struct {
+		name     string
+		userID   int64
+		wantOpen bool
+		wantText string
+	}{
+		{name: "kuzatuvchi", userID: viewerID, wantOpen: true, wantText: "⛔ Kuzatuvchi bu amalni bajara olmaydi"},
+		{name: "konsultant", userID: consultantID, wantOpen: false, wantText: "✅ Xabar javob berildi deb belgilandi!"},
+		{name: "registrda yo'q admin", userID: 503, wantOpen: false, wantText: "✅ Xabar javob berildi deb belgilandi!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := state
+			t.Cleanup(func() { state = previous })
+			state = newTestState(t)
+			calls := newTestBot(t)
+			withTestStaff(t,
+				StaffMember{UserID: viewerID, Name: "Kuzatuvchi", Role: ROLE_VIEWER},
+				StaffMember{UserID: consultantID, Name: "Konsultant", Role: ROLE_CONSULTANT},
+			)
+			state.AddPending(&PendingMessage{GroupID: key.GroupID, MessageID: key.MessageID, Status: "pending", Timestamp: time.Now()})
+
+			handleCallbackQuery(&tgbotapi.CallbackQuery{
+				ID:   "cb1",
+				From: &tgbotapi.User{ID: tt.userID, FirstName: tt.name},
+				Data: "mark_answered_-100_7",
+			})
+
+			msg, ok := state.GetPending(key)
+			if !ok {
+				t.Fatal("xabar topilmadi")
+			}
+			if msg.isOpen() != tt.wantOpen {
+				t.Fatalf("xabar holati %q, ochiq bo'lishi kutilgan: %v", msg.Status, tt.wantOpen)
+			}
+
+			var answers []string
+			for _, call := range calls() {
+				if call.Method == "answerCallbackQuery" {
+					answers = append(answers, call.Params.Get("text"))
+				}
+			}
+			if len(answers) != 1 || answers[0] != tt.wantText {
+				t.Errorf("callback javoblari %q, kutilgan [%q]", answers, tt.wantText)
+			}
+		})
+	}
+}
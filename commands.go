@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Buyruq: handler va yozish huquqi kerakmi (navbatni yoki sozlamalarni o'zgartiradi)
+type adminCommand struct {
+	handler     func(message *tgbotapi.Message)
+	write       bool
+	description string
+}
+
+// Buyruqlar jadvali. init da to'ldiriladi - /help jadvalning o'zini o'qiydi
+var adminCommands map[string]adminCommand
+
+func init() {
+	adminCommands = map[string]adminCommand{
+		"help":       {handleHelpCommand, false, "buyruqlar ro'yxati"},
+		"pending":    {handlePendingCommand, false, "[davlat|guruh|mine] - javobsiz xabarlar"},
+		"stats":      {handleStatsCommand, false, "[today|7d|30d|24h] - statistika"},
+		"ignore":     {handleIgnoreCommand, true, "<id> - javob shart emas deb belgilash"},
+		"reopen":     {handleReopenCommand, true, "<id> - yopilgan xabarni qayta ochish"},
+		"deleted":    {handleDeletedCommand, true, "<id> - o'chirilgan xabarni yopish"},
+		"assign":     {handleAssignCommand, true, "<id> <@username|user_id|-> - mas'ul tayinlash"},
+		"groups":     {handleGroupsCommand, false, "kuzatilayotgan guruhlar"},
+		"whois":      {handleWhoisCommand, false, "<user_id|@username> - foydalanuvchi haqida"},
+		"topic":      {handleTopicCommand, true, "topiclar jadvali (/topic - yordam)"},
+		"staff":      {handleStaffCommand, true, "xodimlar ro'yxati (/staff - yordam)"},
+		"escalation": {handleEscalationCommand, false, "eskalatsiya siyosatlari (/escalation - yordam)"},
+	}
+}
+
+// Buyruqdan foydalanish huquqi. Admin guruhi va menejerlar chatida hamma
+// (registrdagi kuzatuvchilar faqat o'qiydi), shaxsiy chatda faqat registrdagi xodimlar
+func commandAccess(message *tgbotapi.Message) (allowed bool, canWrite bool) {
+	member, registered := staff.Get(message.From.ID)
+	if isStaffChat(message.Chat.ID) {
+		return true, !registered || member.Role.canAnswer()
+	}
+	if message.Chat.IsPrivate() && registered {
+		return true, member.Role.canAnswer()
+	}
+	return false, false
+}
+
+// Xodimlar chatlaridagi va xodimlarning shaxsiy chatidagi buyruqlarni boshqarish
+func handleAdminCommand(message *tgbotapi.Message) {
+	log.Printf("⌨️ Admin buyrug'i: /%s %s (%s dan)", message.Command(), message.CommandArguments(), message.From.FirstName)
+
+	allowed, canWrite := commandAccess(message)
+	if !allowed {
+		log.Printf("🚫 Buyruq rad etildi: %s (ID: %d) xodim emas", message.From.FirstName, message.From.ID)
+		return
+	}
+
+	command, ok := adminCommands[strings.ToLower(message.Command())]
+	if !ok {
+		if message.Chat.IsPrivate() {
+			replyText(message, "❓ Noma'lum buyruq. /help - buyruqlar ro'yxati")
+		}
+		return
+	}
+	if command.write && !canWrite {
+		replyText(message, "⛔ Kuzatuvchi (viewer) bu buyruqni ishlata olmaydi")
+		return
+	}
+	command.handler(message)
+}
+
+// /help - buyruqlar ro'yxati
+func handleHelpCommand(message *tgbotapi.Message) {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("⌨️ Buyruqlar:\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "/%s %s\n", name, adminCommands[name].description)
+	}
+	sb.WriteString("\n<id> - ro'yxatdagi GURUH:XABAR kaliti, xabar havolasi yoki eslatma kartasiga reply")
+	replyText(message, sb.String())
+}
+
+// Buyruqqa matnli javob (javob buyruq yozilgan topicga tushadi)
+func replyText(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("❌ Buyruqqa javob yuborishda xato: %v", err)
+	}
+}
+
+// Buyruqqa HTML javob
+func replyHTML(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("❌ Buyruqqa javob yuborishda xato: %v", err)
+	}
+}
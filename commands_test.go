@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCommandAccess(t *testing.T) {
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+	cfg = &Config{AdminChatID: -100, ManagersChatID: -200}
+	withTestStaff(t,
+		StaffMember{UserID: 1, Name: "Kuzatuvchi", Role: ROLE_VIEWER},
+		StaffMember{UserID: 2, Name: "Menejer", Role: ROLE_MANAGER},
+	)
+
+	tests := []struct {
+		name             string
+		chat             tgbotapi.Chat
+		userID           int64
+		allowed, canEdit bool
+	}{
+		{"admin guruhi, registrda yo'q", tgbotapi.Chat{ID: -100, Type: "supergroup"}, 3, true, true},
+		{"admin guruhi, kuzatuvchi", tgbotapi.Chat{ID: -100, Type: "supergroup"}, 1, true, false},
+		{"menejerlar chati", tgbotapi.Chat{ID: -200, Type: "supergroup"}, 2, true, true},
+		{"menejerlar chati, kuzatuvchi", tgbotapi.Chat{ID: -200, Type: "supergroup"}, 1, true, false},
+		{"shaxsiy chat, xodim", tgbotapi.Chat{ID: 2, Type: "private"}, 2, true, true},
+		{"shaxsiy chat, begona", tgbotapi.Chat{ID: 3, Type: "private"}, 3, false, false},
+		{"mijoz guruhi", tgbotapi.Chat{ID: -300, Type: "supergroup"}, 2, false, false},
+	}
+	for _, tt := range tests {
+		chat := tt.chat
+		message := &tgbotapi.Message{Chat: &chat, From: &tgbotapi.User{ID: tt.userID}}
+		allowed, canWrite := commandAccess(message)
+		if allowed != tt.allowed || canWrite != tt.canEdit {
+			t.Errorf("%s: commandAccess = %v, %v; kutilgan %v, %v", tt.name, allowed, canWrite, tt.allowed, tt.canEdit)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standart sozlamalar - config fayl, env yoki flaglar orqali o'zgartiriladi
+const (
+	DEFAULT_CONFIG_FILE         = "config.json"
+	DEFAULT_ADMIN_CHAT_ID       = -1002816907697 // Adminlar guruhi - bu yerdan xabar olmaydi
+	DEFAULT_REMINDER_DELAY      = 10 * time.Minute
+	DEFAULT_CHECK_INTERVAL      = 30 * time.Second
+	DEFAULT_CONVERSATION_WINDOW = 5 * time.Minute // Shu oraliqdagi ketma-ket xabarlar bitta suhbat
+	DEFAULT_CARD_REFRESH        = 5 * time.Minute // Kartalar va dashboardlardagi kutish vaqtini yangilash
+	DEFAULT_DATA_DIR            = "."
+	DEFAULT_PENDING_FILE        = "pending_messages.json"
+	DEFAULT_GROUPS_FILE         = "groups.json"
+	DEFAULT_BOLT_FILE           = "globuz.db"
+	DEFAULT_TOPICS_FILE         = "topics.json"
+	DEFAULT_STAFF_FILE          = "staff.json"
+	DEFAULT_ESCALATION_FILE     = "escalation.json"
+	DEFAULT_DASHBOARD_FILE      = "dashboards.json"
+	DEFAULT_STAFF_USERNAME      = "globuz" // Eski usul: username da shu so'z bo'lsa xodim
+	DEFAULT_UNASSIGNED_TOPIC    = "Aniqlanmadi"
+	DEFAULT_BACKUP_KEEP         = 20
+	DEFAULT_BACKUP_INTERVAL     = 30 * time.Minute
+	BACKUP_DIR_NAME             = "backups" // JSON fayllar yonidagi backup papkasi
+)
+
+// Xodim javobini aniqlash usullari
+const (
+	ANSWER_MODE_REPLY = "reply" // Faqat mijoz xabariga yoki eslatmaga reply
+	ANSWER_MODE_ANY   = "any"   // Guruhdagi istalgan xodim xabari oldingi xabarlarni yopadi
+)
+
+// Duration - JSON da "10m", "30s" ko'rinishida yoziladigan vaqt oralig'i
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("vaqt oralig'i satr bo'lishi kerak (masalan \"10m\"): %s", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Bot sozlamalari. Ustuvorlik: standart qiymatlar < config fayl < env < flaglar
+type Config struct {
+	BotToken           string   `json:"bot_token"`
+	AdminChatID        int64    `json:"admin_chat_id"`
+	GeneralThreadID    int      `json:"general_thread_id"` // Topic yopiq/topilmasa yuboriladigan topic, 0 - General
+	ManagersChatID     int64    `json:"managers_chat_id"`  // Eskalatsiyaning "managers" pog'onasi, 0 - admin guruhi
+	ManagersThreadID   int      `json:"managers_thread_id"`
+	UnassignedTopic    string   `json:"unassigned_topic"` // Davlat aniqlanmagan xabarlar topici
+	ReminderDelay      Duration `json:"reminder_delay"`
+	CheckInterval      Duration `json:"check_interval"`
+	ConversationWindow Duration `json:"conversation_window"` // 0 - har bir xabar alohida
+	// Jonli kartalar va dashboardlardagi kutish vaqti shu oraliqda yangilanadi
+	// (Telegram guruh uchun tahrirlar sonini cheklaydi). 0 - faqat holat o'zgarganda
+	CardRefreshInterval Duration `json:"card_refresh_interval"`
+	AnswerMode          string   `json:"answer_mode"`        // "reply" yoki "any"
+	AnswerSameThread    bool     `json:"answer_same_thread"` // "any": faqat shu forum topicdagi xabarlar yopiladi
+	AnswerMinLength     int      `json:"answer_min_length"`  // "any": bundan qisqa xabar ("ok", "+") javob emas
+	DataDir             string   `json:"data_dir"`
+	StoreBackend        string   `json:"store_backend"` // "json" yoki "bolt"
+	PendingFile         string   `json:"pending_file"`
+	GroupsFile          string   `json:"groups_file"`
+	BoltFile            string   `json:"bolt_file"`
+	TopicsFile          string   `json:"topics_file"`
+	StaffFile           string   `json:"staff_file"`
+	EscalationFile      string   `json:"escalation_file"`
+	DashboardFile       string   `json:"dashboard_file"`
+	// Registrda ham, guruh adminlarida ham bo'lmagan userlar uchun
+	// username bo'yicha taxmin (mijoz ham shunday username olishi mumkin)
+	StaffUsernameFallback bool     `json:"staff_username_fallback"`
+	StaffUsernamePattern  string   `json:"staff_username_pattern"`
+	BackupKeep            int      `json:"backup_keep"`
+	BackupInterval        Duration `json:"backup_interval"`
+	Debug                 bool     `json:"debug"`
+	AssigneeDM            bool     `json:"assignee_dm"` // Mas'ul xodimga eslatmalarni shaxsiy chatda ham yuborish
+
+	// Ish vaqti kalendari. Berilmasa vaqt 24/7 hisoblanadi
+	WorkingHours *WorkingHoursConfig `json:"working_hours,omitempty"`
+	calendar     *Calendar
+}
+
+// Global sozlamalar
+var cfg *Config
+
+func defaultConfig() *Config {
+	return &Config{
+		AdminChatID:          DEFAULT_ADMIN_CHAT_ID,
+		UnassignedTopic:      DEFAULT_UNASSIGNED_TOPIC,
+		ReminderDelay:        Duration{DEFAULT_REMINDER_DELAY},
+		CheckInterval:        Duration{DEFAULT_CHECK_INTERVAL},
+		ConversationWindow:   Duration{DEFAULT_CONVERSATION_WINDOW},
+		CardRefreshInterval:  Duration{DEFAULT_CARD_REFRESH},
+		AnswerMode:           ANSWER_MODE_REPLY,
+		DataDir:              DEFAULT_DATA_DIR,
+		StoreBackend:         STORE_BACKEND_JSON,
+		PendingFile:          DEFAULT_PENDING_FILE,
+		GroupsFile:           DEFAULT_GROUPS_FILE,
+		BoltFile:             DEFAULT_BOLT_FILE,
+		TopicsFile:           DEFAULT_TOPICS_FILE,
+		StaffFile:            DEFAULT_STAFF_FILE,
+		EscalationFile:       DEFAULT_ESCALATION_FILE,
+		DashboardFile:        DEFAULT_DASHBOARD_FILE,
+		StaffUsernamePattern: DEFAULT_STAFF_USERNAME,
+		BackupKeep:           DEFAULT_BACKUP_KEEP,
+		BackupInterval:       Duration{DEFAULT_BACKUP_INTERVAL},
+	}
+}
+
+// Sozlamalarni yuklash: config fayl, keyin env o'zgaruvchilar, keyin flaglar
+func loadConfig(args []string) (*Config, error) {
+	c := defaultConfig()
+
+	fs := flag.NewFlagSet("globuz-visa-bot", flag.ContinueOnError)
+	configFile := fs.String("config", "", "config fayl yo'li (standart: CONFIG_FILE env yoki "+DEFAULT_CONFIG_FILE+")")
+	token := fs.String("token", "", "Telegram bot tokeni")
+	adminChat := fs.Int64("admin-chat", 0, "adminlar guruhi ID si")
+	reminderDelay := fs.Duration("reminder-delay", 0, "eslatma yuborish kechikishi (masalan 10m)")
+	checkInterval := fs.Duration("check-interval", 0, "eslatmalarni tekshirish oralig'i (masalan 30s)")
+	dataDir := fs.String("data-dir", "", "ma'lumotlar papkasi")
+	storeBackend := fs.String("store", "", "saqlash backendi: json yoki bolt")
+	debug := fs.Bool("debug", false, "Telegram API so'rovlarini logga chiqarish")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 1. Config fayl
+	path := *configFile
+	explicit := path != ""
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DEFAULT_CONFIG_FILE
+	}
+	if err := c.loadFile(path, explicit); err != nil {
+		return nil, err
+	}
+
+	// 2. Env o'zgaruvchilar
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// 3. Flaglar - faqat berilganlari
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "token":
+			c.BotToken = *token
+		case "admin-chat":
+			c.AdminChatID = *adminChat
+		case "reminder-delay":
+			c.ReminderDelay.Duration = *reminderDelay
+		case "check-interval":
+			c.CheckInterval.Duration = *checkInterval
+		case "data-dir":
+			c.DataDir = *dataDir
+		case "store":
+			c.StoreBackend = *storeBackend
+		case "debug":
+			c.Debug = *debug
+		}
+	})
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config faylni o'qish. Fayl aniq ko'rsatilmagan va mavjud bo'lmasa xato emas
+func (c *Config) loadFile(path string, explicit bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("config faylni o'qib bo'lmadi (%s): %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config fayl noto'g'ri (%s): %w", path, err)
+	}
+	return nil
+}
+
+// Env o'zgaruvchilarni qo'llash
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("BOT_TOKEN"); v != "" {
+		c.BotToken = v
+	}
+	if v := os.Getenv("ADMIN_CHAT_ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ADMIN_CHAT_ID son bo'lishi kerak: %q", v)
+		}
+		c.AdminChatID = id
+	}
+	if v := os.Getenv("MANAGERS_CHAT_ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("MANAGERS_CHAT_ID son bo'lishi kerak: %q", v)
+		}
+		c.ManagersChatID = id
+	}
+	if v := os.Getenv("REMINDER_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("REMINDER_DELAY noto'g'ri (masalan 10m): %q", v)
+		}
+		c.ReminderDelay.Duration = d
+	}
+	if v := os.Getenv("CHECK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("CHECK_INTERVAL noto'g'ri (masalan 30s): %q", v)
+		}
+		c.CheckInterval.Duration = d
+	}
+	if v := os.Getenv("CONVERSATION_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("CONVERSATION_WINDOW noto'g'ri (masalan 5m): %q", v)
+		}
+		c.ConversationWindow.Duration = d
+	}
+	if v := os.Getenv("CARD_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("CARD_REFRESH_INTERVAL noto'g'ri (masalan 5m): %q", v)
+		}
+		c.CardRefreshInterval.Duration = d
+	}
+	if v := os.Getenv("ANSWER_MODE"); v != "" {
+		c.AnswerMode = v
+	}
+	if v := os.Getenv("DATA_DIR"); v != "" {
+		c.DataDir = v
+	}
+	if v := os.Getenv("STORE_BACKEND"); v != "" {
+		c.StoreBackend = v
+	}
+	if v := os.Getenv("STAFF_USERNAME_FALLBACK"); v != "" {
+		fallback, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("STAFF_USERNAME_FALLBACK true yoki false bo'lishi kerak: %q", v)
+		}
+		c.StaffUsernameFallback = fallback
+	}
+	if v := os.Getenv("ASSIGNEE_DM"); v != "" {
+		dm, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ASSIGNEE_DM true yoki false bo'lishi kerak: %q", v)
+		}
+		c.AssigneeDM = dm
+	}
+	if v := os.Getenv("DEBUG"); v != "" {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("DEBUG true yoki false bo'lishi kerak: %q", v)
+		}
+		c.Debug = debug
+	}
+	return nil
+}
+
+var botTokenPattern = regexp.MustCompile(`^\d+:[A-Za-z0-9_-]{30,}$`)
+
+// Sozlamalarni tekshirish - barcha xatolar birga qaytariladi
+func (c *Config) validate() error {
+	var errs []string
+
+	if c.BotToken == "" {
+		errs = append(errs, "bot tokeni berilmagan (BOT_TOKEN env, -token flag yoki config fayldagi bot_token)")
+	} else if !botTokenPattern.MatchString(c.BotToken) {
+		errs = append(errs, "bot tokeni formati noto'g'ri (kutilgan: 123456:ABC...)")
+	}
+	if c.AdminChatID >= 0 {
+		errs = append(errs, fmt.Sprintf("admin_chat_id guruh ID si bo'lishi kerak (manfiy son): %d", c.AdminChatID))
+	}
+	if c.GeneralThreadID < 0 {
+		errs = append(errs, "general_thread_id manfiy bo'lmasligi kerak")
+	}
+	if c.ManagersChatID > 0 {
+		errs = append(errs, fmt.Sprintf("managers_chat_id guruh ID si bo'lishi kerak (manfiy son): %d", c.ManagersChatID))
+	}
+	if c.ManagersThreadID < 0 {
+		errs = append(errs, "managers_thread_id manfiy bo'lmasligi kerak")
+	}
+	if strings.TrimSpace(c.UnassignedTopic) == "" {
+		errs = append(errs, "unassigned_topic bo'sh bo'lmasligi kerak")
+	}
+	if c.ReminderDelay.Duration <= 0 {
+		errs = append(errs, "reminder_delay musbat bo'lishi kerak")
+	}
+	if c.CheckInterval.Duration <= 0 {
+		errs = append(errs, "check_interval musbat bo'lishi kerak")
+	}
+	if c.ConversationWindow.Duration < 0 {
+		errs = append(errs, "conversation_window manfiy bo'lmasligi kerak")
+	}
+	if c.CardRefreshInterval.Duration < 0 {
+		errs = append(errs, "card_refresh_interval manfiy bo'lmasligi kerak")
+	}
+	if c.AnswerMode != ANSWER_MODE_REPLY && c.AnswerMode != ANSWER_MODE_ANY {
+		errs = append(errs, fmt.Sprintf("answer_mode reply yoki any bo'lishi kerak: %q", c.AnswerMode))
+	}
+	if c.AnswerMinLength < 0 {
+		errs = append(errs, "answer_min_length manfiy bo'lmasligi kerak")
+	}
+	if c.StoreBackend != STORE_BACKEND_JSON && c.StoreBackend != STORE_BACKEND_BOLT {
+		errs = append(errs, fmt.Sprintf("store_backend json yoki bolt bo'lishi kerak: %q", c.StoreBackend))
+	}
+	if c.StaffUsernameFallback && strings.TrimSpace(c.StaffUsernamePattern) == "" {
+		errs = append(errs, "staff_username_fallback yoqilgan, lekin staff_username_pattern bo'sh")
+	}
+	if c.BackupKeep < 1 {
+		errs = append(errs, "backup_keep kamida 1 bo'lishi kerak")
+	}
+	if c.BackupInterval.Duration < 0 {
+		errs = append(errs, "backup_interval manfiy bo'lmasligi kerak")
+	}
+
+	if c.WorkingHours != nil {
+		calendar, err := newCalendar(c.WorkingHours)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		c.calendar = calendar
+	}
+
+	if c.DataDir == "" {
+		errs = append(errs, "data_dir bo'sh bo'lmasligi kerak")
+	} else if info, err := os.Stat(c.DataDir); err != nil {
+		if !os.IsNotExist(err) {
+			errs = append(errs, fmt.Sprintf("data_dir tekshirib bo'lmadi: %v", err))
+		} else if err := os.MkdirAll(c.DataDir, 0755); err != nil {
+			errs = append(errs, fmt.Sprintf("data_dir yaratib bo'lmadi: %v", err))
+		}
+	} else if !info.IsDir() {
+		errs = append(errs, fmt.Sprintf("data_dir papka emas: %s", c.DataDir))
+	}
+
+	if len(errs) > 0 {
+		return errors.New("sozlamalar xato:\n  - " + strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// Ma'lumotlar papkasidagi fayl yo'li (absolyut yo'llar o'zgarmaydi)
+func (c *Config) dataPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.DataDir, name)
+}
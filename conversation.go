@@ -0,0 +1,47 @@
+package main
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// Suhbatdagi keyingi xabar: mijoz bir necha xabar ketma-ket yozsa ular
+// bitta ochiq xabarga (suhbatga) qo'shiladi
+type MessagePart struct {
+	MessageID int       `json:"message_id"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Suhbatdagi oxirgi xabar vaqti
+func (m *PendingMessage) lastActivity() time.Time {
+	if n := len(m.Parts); n > 0 {
+		return m.Parts[n-1].Timestamp
+	}
+	return m.Timestamp
+}
+
+// Xabar shu suhbatga tegishlimi (birinchi xabar yoki keyingilaridan biri)
+func (m *PendingMessage) containsMessage(messageID int) bool {
+	if m.MessageID == messageID {
+		return true
+	}
+	for _, part := range m.Parts {
+		if part.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+// Karta va ro'yxatlarda ko'rsatiladigan matn chegarasi (belgilar)
+const maxShownTextLen = 1500
+
+// Uzun matnni qisqartirish
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit]) + "…"
+}
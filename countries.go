@@ -0,0 +1,249 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Davlatlar uchun muqobil nomlar: o'zbek (lotin va kirill), rus va ingliz
+// yozilishlari. Kalitlar topic jadvali / visa katalogidagi nomlar bilan bir xil.
+// Davlatning asosiy nomi har doim alias hisoblanadi, bu yerda takrorlash shart emas
+var countryAliases = map[string][]string{
+	"UK":           {"united kingdom", "england", "britain", "great britain", "london", "angliya", "britaniya", "buyuk britaniya", "англия", "британия", "великобритания", "буюк британия", "лондон"},
+	"USA":          {"united states", "america", "amerika", "aqsh", "сша", "америка", "ақш", "акш"},
+	"Schengen":     {"shengen", "europe", "yevropa", "evropa", "шенген", "европа", "йевропа"},
+	"Canada":       {"kanada", "канада"},
+	"Australia":    {"avstraliya", "австралия"},
+	"New Zealand":  {"yangi zelandiya", "новая зеландия", "янги зеландия"},
+	"Japan":        {"yaponiya", "япония"},
+	"Brazil":       {"braziliya", "бразилия"},
+	"Saudi Arabia": {"saudi", "ksa", "saudiya", "saudiya arabistoni", "саудовская аравия", "саудия", "саудия арабистони"},
+	"India":        {"hindiston", "индия", "ҳиндистон", "хиндистон"},
+	"South Africa": {"janubiy afrika", "юар", "южная африка", "жанубий африка"},
+	"Seychelles":   {"seyshel", "seyshel orollari", "сейшелы", "сейшел", "сейшельские острова"},
+	"Uganda":       {"уганда"},
+	"Kuwait":       {"quvayt", "kuvayt", "кувейт", "қувайт"},
+	"Bahrain":      {"bahrayn", "бахрейн", "баҳрайн"},
+	"Israel":       {"isroil", "израиль", "исроил"},
+	"Pakistan":     {"pokiston", "пакистан", "покистон"},
+	"Vietnam":      {"viet nam", "vyetnam", "вьетнам", "ветнам"},
+	"Albania":      {"albaniya", "албания"},
+	"Taiwan":       {"tayvan", "тайвань", "тайван"},
+	"Turkey":       {"turkiya", "turkiye", "türkiye", "турция", "туркия"},
+	"UAE":          {"emirates", "birlashgan arab amirliklari", "dubai", "dubay", "оаэ", "эмираты", "дубай", "бирлашган араб амирликлари"},
+	"Qatar":        {"катар", "қатар"},
+	"Oman":         {"ummon", "оман", "уммон"},
+	"Jordan":       {"iordaniya", "иордания"},
+	"Egypt":        {"misr", "египет", "миср"},
+	"Morocco":      {"marokash", "марокко", "марокаш"},
+	"Tunisia":      {"tunis", "тунис"},
+	"Kenya":        {"keniya", "кения"},
+	"Tanzania":     {"tanzaniya", "танзания"},
+	"Ethiopia":     {"efiopiya", "эфиопия"},
+	"Peru":         {"перу"},
+	"Argentina":    {"аргентина"},
+	"Hong Kong":    {"hongkong", "gonkong", "гонконг"},
+	"Ireland":      {"irlandiya", "ирландия"},
+	"Cyprus":       {"kipr", "кипр"},
+	"Zimbabwe":     {"zimbabve", "зимбабве"},
+}
+
+// So'z oxiridagi qo'shimchalar: "Angliyaga", "Kanadadan", "Англияга"
+var aliasSuffixes = []string{
+	"ga", "ka", "qa", "da", "ta", "dan", "tan", "ni", "ning", "dagi", "gacha", "lik", "lar",
+	"га", "ка", "қа", "да", "та", "дан", "тан", "ни", "нинг", "даги", "гача", "лик", "лар",
+}
+
+// Rus tilidagi kelishik qo'shimchalari - so'nggi unli o'rniga: "Англии", "Канаду"
+var aliasEndings = []string{"а", "у", "е", "ы", "и", "ю", "ой", "ом", "ей", "ии", "ию", "ией"}
+
+// Qo'shimcha qabul qiladigan aliasning minimal uzunligi: "UK", "USA" kabi
+// qisqa nomlar faqat alohida so'z sifatida mos keladi
+const minSuffixAliasLen = 4
+
+// Matndan topilgan davlat
+type countryMatch struct {
+	Country  string
+	Count    int // Necha marta eslatilgan
+	Position int // Birinchi eslatilgan so'z tartibi
+}
+
+// Matnni kichik harfga o'tkazish va apostrof turlarini bittaga keltirish
+func normalizeCountryText(text string) string {
+	text = strings.ToLower(text)
+	return strings.NewReplacer("ʻ", "'", "’", "'", "‘", "'", "`", "'", "´", "'", "ʼ", "'", "ё", "е").Replace(text)
+}
+
+// Matnni so'zlarga bo'lish: harf, raqam va so'z ichidagi apostroflar saqlanadi
+func tokenizeCountryText(text string) []string {
+	fields := strings.FieldsFunc(normalizeCountryText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if f = strings.Trim(f, "'"); f != "" {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// So'z alias bilan (qo'shimchalarni hisobga olib) mos kelishini tekshirish
+func tokenMatchesAlias(token, alias string) bool {
+	if token == alias {
+		return true
+	}
+	if utf8.RuneCountInString(alias) < minSuffixAliasLen {
+		return false
+	}
+	if strings.HasPrefix(token, alias) {
+		rest := token[len(alias):]
+		for _, suffix := range aliasSuffixes {
+			if rest == suffix {
+				return true
+			}
+		}
+	}
+	// Unli bilan tugagan rus so'zlari: англия -> англии
+	last, size := utf8.DecodeLastRuneInString(alias)
+	if strings.ContainsRune("аяеоaeo", last) {
+		stem := alias[:len(alias)-size]
+		if strings.HasPrefix(token, stem) {
+			rest := token[len(stem):]
+			for _, ending := range aliasEndings {
+				if rest == ending {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Davlatlar aliaslari indeksi: har bir ma'lum davlat uchun so'zlarga
+// bo'lingan aliaslar. Har xabarda qayta qurilmasligi uchun topic jadvali
+// o'zgargandagina quriladi (TopicRegistry.countryIndex)
+type countryIndex struct {
+	countries []string // Nom bo'yicha tartiblangan
+	aliases   map[string][][]string
+}
+
+// Topic jadvali bo'yicha indeks qurish
+func buildCountryIndex(list []TopicInfo) *countryIndex {
+	idx := &countryIndex{aliases: make(map[string][][]string)}
+	for _, country := range knownCountries(list) {
+		names := append([]string{country}, countryAliases[country]...)
+		for _, name := range names {
+			if tokens := tokenizeCountryText(name); len(tokens) > 0 {
+				idx.aliases[country] = append(idx.aliases[country], tokens)
+			}
+		}
+	}
+	for country := range idx.aliases {
+		idx.countries = append(idx.countries, country)
+	}
+	sort.Strings(idx.countries)
+	return idx
+}
+
+// Matndagi barcha davlatlarni topish. Natija quyidagi tartibda:
+// ko'p eslatilgan birinchi, teng bo'lsa oldinroq eslatilgan, keyin nom bo'yicha
+func findCountriesInText(text string) []countryMatch {
+	tokens := tokenizeCountryText(text)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx := topics.countryIndex()
+
+	// Har bir joyda eng uzun mos alias olinadi va uning so'zlari o'tkazib
+	// yuboriladi: "Buyuk Britaniya" bir marta hisoblanadi, "Britaniya" bilan ikki emas
+	found := make(map[string]*countryMatch)
+	for i := 0; i < len(tokens); {
+		bestCountry, bestLen := "", 0
+		for _, country := range idx.countries {
+			for _, alias := range idx.aliases[country] {
+				if len(alias) > bestLen && aliasMatchesAt(tokens, i, alias) {
+					bestCountry, bestLen = country, len(alias)
+				}
+			}
+		}
+		if bestLen == 0 {
+			i++
+			continue
+		}
+
+		m := found[bestCountry]
+		if m == nil {
+			m = &countryMatch{Country: bestCountry, Position: i}
+			found[bestCountry] = m
+		}
+		m.Count++
+		i += bestLen
+	}
+
+	matches := make([]countryMatch, 0, len(found))
+	for _, m := range found {
+		matches = append(matches, *m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		if matches[i].Position != matches[j].Position {
+			return matches[i].Position < matches[j].Position
+		}
+		return matches[i].Country < matches[j].Country
+	})
+	return matches
+}
+
+// Ko'p so'zli alias tokens[i:] dan boshlanishini tekshirish.
+// Qo'shimcha faqat oxirgi so'zda bo'lishi mumkin
+func aliasMatchesAt(tokens []string, i int, alias []string) bool {
+	if i+len(alias) > len(tokens) {
+		return false
+	}
+	for j, word := range alias {
+		if j == len(alias)-1 {
+			return tokenMatchesAlias(tokens[i+j], word)
+		}
+		if tokens[i+j] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Guruh nomidan davlat nomlarini aniqlash (nomda uchragan tartibda)
+func extractCountriesFromGroupTitle(groupTitle string) []string {
+	matches := findCountriesInText(groupTitle)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Position < matches[j].Position
+	})
+
+	countries := make([]string, 0, len(matches))
+	for _, m := range matches {
+		countries = append(countries, m.Country)
+	}
+	return countries
+}
+
+// Xabar matnidan davlat nomini topish
+func findCountryInText(text string) string {
+	if matches := findCountriesInText(text); len(matches) > 0 {
+		return matches[0].Country
+	}
+	return ""
+}
+
+// Guruh nomidan davlat nomini topish
+func findCountryFromGroupTitle(groupTitle string) string {
+	countries := extractCountriesFromGroupTitle(groupTitle)
+	if len(countries) > 0 {
+		return countries[0]
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Davlatlarni aniqlash topic jadvali va cfg ga bog'liq - boshlang'ich jadval olinadi
+func withDefaultTopics(t *testing.T) {
+	t.Helper()
+	previousTopics, previousCfg := topics, cfg
+	t.Cleanup(func() { topics, cfg = previousTopics, previousCfg })
+	cfg = &Config{UnassignedTopic: "Aniqlanmadi"}
+	topics = &TopicRegistry{topics: defaultTopics(-100)}
+}
+
+func TestFindCountriesInText(t *testing.T) {
+	withDefaultTopics(t)
+
+	tests := []struct {
+		name string
+		text string
+		want []countryMatch
+	}{
+		{name: "bo'sh matn", text: "", want: nil},
+		{name: "so'z ichidagi UK hisoblanmaydi", text: "bukun kelaman, bugun ham", want: nil},
+		{name: "alohida UK", text: "UK vizasi kerak", want: []countryMatch{{Country: "UK", Count: 1, Position: 0}}},
+		{name: "rus kelishigi", text: "Виза для Англии", want: []countryMatch{{Country: "UK", Count: 1, Position: 2}}},
+		{name: "o'zbek qo'shimchasi", text: "Kanadaga hujjat", want: []countryMatch{{Country: "Canada", Count: 1, Position: 0}}},
+		{
+			name: "Buyuk Britaniya bir marta",
+			text: "Buyuk Britaniya vizasi",
+			want: []countryMatch{{Country: "UK", Count: 1, Position: 0}},
+		},
+		{
+			name: "ko'p eslatilgan birinchi",
+			text: "Kanada yoki Buyuk Britaniya? Angliya narxi, Londonga chipta",
+			want: []countryMatch{
+				{Country: "UK", Count: 3, Position: 2},
+				{Country: "Canada", Count: 1, Position: 0},
+			},
+		},
+		{
+			name: "teng bo'lsa oldinroq eslatilgan",
+			text: "Yaponiya va Hindiston",
+			want: []countryMatch{
+				{Country: "Japan", Count: 1, Position: 0},
+				{Country: "India", Count: 1, Position: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCountriesInText(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCountriesInText(%q) = %+v, kutilgan %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCountryInText(t *testing.T) {
+	withDefaultTopics(t)
+
+	tests := map[string]string{
+		"bukun":                       "",
+		"Англии нужна виза":           "UK",
+		"Buyuk Britaniyaga boraman":   "UK",
+		"Shengen yoki AQSH, AQShga":   "USA",
+		"Aniqlanmadi topiciga yozing": "",
+	}
+	for text, want := range tests {
+		if got := findCountryInText(text); got != want {
+			t.Errorf("findCountryInText(%q) = %q, kutilgan %q", text, got, want)
+		}
+	}
+}
+
+func TestCountryIndexFollowsTopicTable(t *testing.T) {
+	withDefaultTopics(t)
+	registry, err := loadTopics(filepath.Join(t.TempDir(), "topics.json"), -100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics = registry
+
+	if got := findCountryInText("Moldovaga viza"); got != "" {
+		t.Fatalf("jadvalda yo'q davlat aniqlandi: %q", got)
+	}
+	if err := topics.Put(TopicInfo{ChatID: -100, MessageThreadID: 40, Text: "Moldova"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := findCountryInText("Moldovaga viza"); got != "Moldova" {
+		t.Fatalf("yangi topic qo'shilgandan keyin %q, kutilgan Moldova", got)
+	}
+	if _, err := topics.Remove("Moldova"); err != nil {
+		t.Fatal(err)
+	}
+	if got := findCountryInText("Moldovaga viza"); got != "" {
+		t.Fatalf("topic o'chirilgandan keyin %q aniqlandi", got)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Global dashboard kaliti (qolganlari davlat nomi)
+const globalDashboardKey = "*"
+
+// Telegram xabar chegarasi 4096 - sarlavha va "yana N ta" uchun joy qoldiriladi
+const dashboardMaxLen = 3800
+
+// Pin qilingan dashboard xabari. Digest va EditedAt faylga yoziladi -
+// qayta ishga tushganda barcha dashboardlar birdaniga tahrirlanmasin
+type DashboardInfo struct {
+	ChatID    int64     `json:"chat_id"`
+	ThreadID  int       `json:"thread_id,omitempty"`
+	MessageID int       `json:"message_id"`
+	Digest    string    `json:"digest,omitempty"`    // Xabarlar ro'yxati va holatlari (kutish vaqtisiz)
+	EditedAt  time.Time `json:"edited_at,omitempty"` // Oxirgi tahrir - faqat vaqt o'zgargan bo'lsa kamdan-kam tahrirlanadi
+	text      string    // Oxirgi yozilgan matn - o'zgarmagan bo'lsa tahrirlanmaydi
+}
+
+type DashboardsData struct {
+	Dashboards map[string]DashboardInfo `json:"dashboards"`
+}
+
+// Dashboard xabarlari jadvali: davlat (yoki global) -> pin qilingan xabar.
+// dashboards.json faylida saqlanadi - qayta ishga tushganda eski xabarlar tahrirlanadi
+type DashboardRegistry struct {
+	mu     sync.Mutex
+	path   string
+	boards map[string]DashboardInfo
+}
+
+// Global dashboard jadvali
+var dashboards *DashboardRegistry
+
+// Jadvalni fayldan yuklash. Fayl yo'q bo'lsa bo'sh jadval
+func loadDashboards(path string) (*DashboardRegistry, error) {
+	r := &DashboardRegistry{path: path, boards: make(map[string]DashboardInfo)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dashboard faylini o'qib bo'lmadi: %w", err)
+	}
+
+	var dashboardsData DashboardsData
+	if err := json.Unmarshal(data, &dashboardsData); err != nil {
+		return nil, fmt.Errorf("dashboard fayli noto'g'ri (%s): %w", path, err)
+	}
+	for key, info := range dashboardsData.Dashboards {
+		r.boards[key] = info
+	}
+	return r, nil
+}
+
+// Jadvalni faylga yozish (lock ostida chaqiriladi)
+func (r *DashboardRegistry) saveLocked() error {
+	data, err := json.MarshalIndent(DashboardsData{Dashboards: r.boards}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data, 0644)
+}
+
+// Dashboard xabarini topish
+func (r *DashboardRegistry) Get(key string) (DashboardInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, ok := r.boards[key]
+	return info, ok
+}
+
+// Dashboard xabarini yozish. Faqat matn o'zgargan bo'lsa (tahrir qilinmagan)
+// faylga saqlanmaydi
+func (r *DashboardRegistry) Put(key string, info DashboardInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.boards[key]
+	r.boards[key] = info
+	if exists && old.ChatID == info.ChatID && old.ThreadID == info.ThreadID && old.MessageID == info.MessageID &&
+		old.Digest == info.Digest && old.EditedAt.Equal(info.EditedAt) {
+		return
+	}
+	if err := r.saveLocked(); err != nil {
+		log.Printf("❌ Dashboard jadvalini saqlashda xato: %v", err)
+	}
+}
+
+// Dashboardlar kalitlari (saqlangan barcha davlatlar)
+func (r *DashboardRegistry) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.boards))
+	for key := range r.boards {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Kutish vaqtini qisqa ko'rsatish (daqiqa aniqligida - dashboard har
+// tekshiruvda emas, daqiqada ko'pi bilan bir marta o'zgaradi)
+func formatAge(d time.Duration) string {
+	minutes := int(d.Minutes())
+	switch {
+	case minutes < 1:
+		return "<1 daq"
+	case minutes < 60:
+		return fmt.Sprintf("%d daq", minutes)
+	case minutes < 24*60:
+		return fmt.Sprintf("%d soat %d daq", minutes/60, minutes%60)
+	default:
+		return fmt.Sprintf("%d kun %d soat", minutes/(24*60), minutes%(24*60)/60)
+	}
+}
+
+// Dashboard mazmunining kutish vaqtisiz izi: ro'yxat yoki holat o'zgarsa
+// dashboard darhol, faqat kutish vaqtlari o'zgarsa card_refresh_interval da yangilanadi
+func dashboardDigest(messages []*PendingMessage, now time.Time) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&sb, "%s|%s|%s|%d|%v\n", msg.Key(), msg.Status, msg.Country, msg.AssigneeID, msg.snoozed(now))
+	}
+	return sb.String()
+}
+
+// Dashboard matni (HTML). Eng uzoq kutayotganlar birinchi
+func dashboardText(title string, messages []*PendingMessage, withCountry bool, now time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📊 <b>%s</b>\n", html.EscapeString(title))
+	if len(messages) == 0 {
+		sb.WriteString("\n✅ Javobsiz xabar yo'q")
+		return sb.String()
+	}
+
+	overdue := 0
+	for _, msg := range messages {
+		if msg.Status == "overdue" {
+			overdue++
+		}
+	}
+	fmt.Fprintf(&sb, "⏳ Javobsiz: %d ta", len(messages))
+	if overdue > 0 {
+		fmt.Fprintf(&sb, " (⛔ muddati o'tgan: %d)", overdue)
+	}
+	sb.WriteString("\n\n")
+
+	for i, msg := range messages {
+		icon := "🔸"
+		if msg.Status == "overdue" {
+			icon = "⛔"
+		} else if msg.snoozed(now) {
+			icon = "💤"
+		}
+		line := fmt.Sprintf("%s %s — @%s — %s",
+			icon, html.EscapeString(msg.GroupTitle), html.EscapeString(msg.Username), formatAge(now.Sub(msg.Timestamp)))
+		if withCountry {
+			line += " — " + html.EscapeString(msg.Country)
+		}
+		if msg.AssigneeID != 0 {
+			line += " — 🙋 " + html.EscapeString(msg.AssigneeName)
+		}
+		line += fmt.Sprintf(` — <a href="%s">ochish</a>`+"\n", messageLink(msg))
+
+		if sb.Len()+len(line) > dashboardMaxLen {
+			fmt.Fprintf(&sb, "… va yana %d ta", len(messages)-i)
+			break
+		}
+		sb.WriteString(line)
+	}
+	fmt.Fprintf(&sb, "\n🔄 %s", now.Format("02.01.2006 15:04"))
+	return sb.String()
+}
+
+// Dashboardlarni yangilash: har bir davlat topicidagi va global pin
+// qilingan xabar joyida tahrirlanadi. Reminder loop har tekshiruvda chaqiradi
+func refreshDashboards() {
+	now := time.Now()
+	waiting := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.isOpen()
+	})
+
+	byCountry := make(map[string][]*PendingMessage)
+	for _, msg := range waiting {
+		if msg.Country == "" {
+			msg.Country = detectCountry(msg)
+		}
+		byCountry[msg.Country] = append(byCountry[msg.Country], msg)
+	}
+
+	updateDashboard(globalDashboardKey, cfg.AdminChatID, cfg.GeneralThreadID,
+		dashboardText("Barcha javobsiz xabarlar", waiting, true, now), dashboardDigest(waiting, now))
+
+	// Javobsiz xabari bor davlatlar va avval dashboard ochilgan davlatlar
+	countries := make(map[string]bool)
+	for country := range byCountry {
+		countries[country] = true
+	}
+	for _, key := range dashboards.Keys() {
+		if key != globalDashboardKey {
+			countries[key] = true
+		}
+	}
+	names := make([]string, 0, len(countries))
+	for country := range countries {
+		names = append(names, country)
+	}
+	sort.Strings(names)
+
+	for _, country := range names {
+		topic, ok := topics.Find(country)
+		if !ok {
+			continue // Topic hali yaratilmagan - xabar global dashboardda ko'rinadi
+		}
+		updateDashboard(country, topic.ChatID, topic.MessageThreadID,
+			dashboardText(country+" - javobsiz xabarlar", byCountry[country], false, now), dashboardDigest(byCountry[country], now))
+	}
+}
+
+// Bitta dashboardni tahrirlash, bo'lmasa yangisini yuborib pin qilish
+func updateDashboard(key string, chatID int64, threadID int, text, digest string) {
+	now := time.Now()
+	info, exists := dashboards.Get(key)
+	if exists && info.ChatID == chatID && info.ThreadID == threadID {
+		if info.text == text {
+			return
+		}
+		// Faqat kutish vaqtlari o'zgargan - guruhdagi tahrirlar limitini tejash.
+		// Qayta ishga tushgandan keyin ham ishlaydi: Digest va EditedAt fayldan olinadi
+		if !info.EditedAt.IsZero() && info.Digest == digest && (cfg.CardRefreshInterval.Duration <= 0 || now.Sub(info.EditedAt) < cfg.CardRefreshInterval.Duration) {
+			return
+		}
+		edit := tgbotapi.NewEditMessageText(chatID, info.MessageID, text)
+		edit.ParseMode = tgbotapi.ModeHTML
+		edit.DisableWebPagePreview = true
+		_, err := bot.Request(edit)
+		if err == nil || apiErrorContains(err, "message is not modified") {
+			info.text, info.Digest, info.EditedAt = text, digest, now
+			dashboards.Put(key, info)
+			return
+		}
+		if !apiErrorContains(err, "message to edit not found") {
+			log.Printf("❌ Dashboardni yangilashda xato (%s): %v", key, err)
+			return
+		}
+		log.Printf("⚠️ Dashboard xabari o'chirilgan (%s), yangisi yuboriladi", key)
+	}
+
+	// Dashboard topicning o'zida turishi kerak - umumiy topicga fallback qilinmaydi
+	sent, err := sendThreadMessage(ThreadMessageConfig{
+		ChatID:                chatID,
+		MessageThreadID:       threadID,
+		Text:                  text,
+		ParseMode:             tgbotapi.ModeHTML,
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		log.Printf("❌ Dashboard yuborishda xato (%s): %v", key, err)
+		return
+	}
+
+	pin := tgbotapi.PinChatMessageConfig{ChatID: chatID, MessageID: sent.MessageID, DisableNotification: true}
+	if _, err := bot.Request(pin); err != nil {
+		log.Printf("⚠️ Dashboardni pin qilib bo'lmadi (%s): %v", key, err)
+	}
+
+	dashboards.Put(key, DashboardInfo{ChatID: chatID, ThreadID: threadID, MessageID: sent.MessageID, Digest: digest, EditedAt: now, text: text})
+	log.Printf("📌 Dashboard yaratildi: %s (MSG ID: %d)", key, sent.MessageID)
+}
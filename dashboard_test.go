@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Qayta ishga tushgandan keyin faqat kutish vaqtlari o'zgargan dashboard
+// darhol tahrirlanmasligi kerak - Digest va EditedAt fayldan tiklanadi
+func TestDashboardThrottleSurvivesRestart(t *testing.T) {
+	previousCfg, previousBoards := cfg, dashboards
+	t.Cleanup(func() { cfg, dashboards = previousCfg, previousBoards })
+	cfg = &Config{CardRefreshInterval: Duration{10 * time.Minute}}
+	calls := newTestBot(t)
+
+	path := filepath.Join(t.TempDir(), "dashboards.json")
+	var err error
+	if dashboards, err = loadDashboards(path); err != nil {
+		t.Fatal(err)
+	}
+	updateDashboard("UK", -100, 2, "UK: 1 ta, 5 daq", "digest-1")
+
+	// Qayta ishga tushish
+	if dashboards, err = loadDashboards(path); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := dashboards.Get("UK")
+	if !ok || info.Digest != "digest-1" || info.EditedAt.IsZero() {
+		t.Fatalf("dashboard fayldan to'liq tiklanmadi: %+v", info)
+	}
+
+	edits := func() int {
+		n := 0
+		for _, call := range calls() {
+			if call.Method == "editMessageText" {
+				n++
+			}
+		}
+		return n
+	}
+
+	updateDashboard("UK", -100, 2, "UK: 1 ta, 6 daq", "digest-1")
+	if n := edits(); n != 0 {
+		t.Fatalf("faqat vaqt o'zgargan dashboard %d marta tahrirlandi", n)
+	}
+	updateDashboard("UK", -100, 2, "UK: 2 ta, 6 daq", "digest-2")
+	if n := edits(); n != 1 {
+		t.Fatalf("ro'yxat o'zgargan dashboard %d marta tahrirlandi, kutilgan 1", n)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Mijoz xabarini tahrirlaganda eski matn shu yerda qoladi
+type MessageEdit struct {
+	MessageID int       `json:"message_id"`
+	OldText   string    `json:"old_text"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// Birinchi xabar matni: Text dan suhbatning keyingi xabarlari olib tashlanadi
+func (m *PendingMessage) firstText() string {
+	var parts []string
+	for _, part := range m.Parts {
+		if part.Text != "" {
+			parts = append(parts, part.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return m.Text
+	}
+	joined := strings.Join(parts, "\n")
+	if m.Text == joined {
+		return ""
+	}
+	return strings.TrimSuffix(m.Text, "\n"+joined)
+}
+
+// Suhbatdagi bitta xabar matnini almashtirish va Text ni qayta yig'ish.
+// Eski matn tarixga yoziladi. Matn o'zgarmagan bo'lsa false
+func (m *PendingMessage) applyEdit(messageID int, text string, now time.Time) bool {
+	first := m.firstText()
+	old, found := first, messageID == m.MessageID
+	if found {
+		first = text
+	} else {
+		for i := range m.Parts {
+			if m.Parts[i].MessageID == messageID {
+				old, found = m.Parts[i].Text, true
+				m.Parts[i].Text = text
+			}
+		}
+	}
+	// Fayl izohi ham yangilanadi
+	for i := range m.Media {
+		if m.Media[i].MessageID == messageID && m.Media[i].Caption != text {
+			m.Media[i].Caption = text
+		}
+	}
+	if !found || old == text {
+		return false
+	}
+
+	texts := []string{}
+	if first != "" {
+		texts = append(texts, first)
+	}
+	for _, part := range m.Parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	m.Text = strings.Join(texts, "\n")
+	m.Edits = append(m.Edits, MessageEdit{MessageID: messageID, OldText: old, EditedAt: now})
+	return true
+}
+
+// Mijoz xabarini tahrirlasa ochiq xabar matni yangilanadi va jonli karta qayta chiziladi
+func handleEditedMessage(message *tgbotapi.Message) {
+	if message.Chat.Type != "group" && message.Chat.Type != "supergroup" {
+		return
+	}
+	if isStaffChat(message.Chat.ID) {
+		return
+	}
+
+	key, found := state.FindConversation(message.Chat.ID, message.MessageID)
+	if !found {
+		return
+	}
+
+	edited := false
+	updated, exists := state.UpdatePending(key, func(msg *PendingMessage) bool {
+		edited = msg.applyEdit(message.MessageID, messageText(message), time.Now())
+		return edited
+	})
+	if !exists || !edited {
+		return
+	}
+
+	log.Printf("✏️ Mijoz xabarini tahrirladi: MSG %d (%s), %d-tahrir", message.MessageID, key, len(updated.Edits))
+	refreshReminderCards(updated)
+}
+
+// Xabar hali guruhda bormi: jim nusxa ko'chirib ko'riladi va nusxa darhol o'chiriladi.
+// Telegram o'chirilgan xabarlar haqida bot ga xabar bermaydi
+func sourceMessageExists(msg *PendingMessage, chatID int64, threadID int) (bool, error) {
+	copiedID, err := copyMessage(CopyMessageConfig{
+		ChatID:              chatID,
+		MessageThreadID:     threadID,
+		FromChatID:          msg.GroupID,
+		MessageID:           msg.MessageID,
+		DisableNotification: true,
+	})
+	if err != nil {
+		if apiErrorContains(err, "message to copy not found") {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := bot.Request(tgbotapi.NewDeleteMessage(chatID, copiedID)); err != nil {
+		log.Printf("⚠️ Tekshiruv nusxasini o'chirib bo'lmadi (Chat: %d, MSG ID: %d): %v", chatID, copiedID, err)
+	}
+	return true, nil
+}
+
+// Manba xabari o'chirilgan ochiq xabarni "deleted" holatida yopish.
+// Natija foydalanuvchiga ko'rsatiladigan matn
+func closeDeletedTicket(key PendingKey, user *tgbotapi.User, chatID int64, threadID int) string {
+	msg, exists := state.GetPending(key)
+	if !exists {
+		return fmt.Sprintf("❓ %s xabari topilmadi", key)
+	}
+	if !msg.isOpen() {
+		return fmt.Sprintf("ℹ️ %s allaqachon yopilgan (%s)", key, msg.Status)
+	}
+
+	stillThere, err := sourceMessageExists(msg, chatID, threadID)
+	if err != nil {
+		log.Printf("❌ %s xabarini tekshirib bo'lmadi: %v", key, err)
+		return "❌ Xabarni tekshirib bo'lmadi (/ignore bilan yopish mumkin)"
+	}
+	if stillThere {
+		return "ℹ️ Xabar guruhda hali mavjud"
+	}
+
+	closed, ok := state.CloseTicket(key, "deleted", user.ID, displayName(user))
+	if !ok {
+		return fmt.Sprintf("❓ %s xabari topilmadi", key)
+	}
+	log.Printf("🗑️ %s manba xabari o'chirilgan - yopildi (%s)", key, displayName(user))
+	resolveSentMessages(closed)
+	return fmt.Sprintf("🗑️ %s - xabar o'chirilgan, yopildi", key)
+}
+
+// "🗑 O'chirilgan" tugmasi
+func handleDeletedCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if !canUseCardActions(callback.From) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Kuzatuvchi bu amalni bajara olmaydi"))
+		return
+	}
+	key, ok := parseMarkAnsweredData(payload)
+	if !ok || callback.Message == nil {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
+		return
+	}
+
+	threadID := 0
+	if msg, exists := state.GetPending(key); exists {
+		for _, card := range msg.SentReminders {
+			if card.ChatID == callback.Message.Chat.ID && card.MessageID == callback.Message.MessageID {
+				threadID = card.ThreadID
+			}
+		}
+	}
+	bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, closeDeletedTicket(key, callback.From, callback.Message.Chat.ID, threadID)))
+}
+
+// /deleted <id> - manba xabari o'chirilgan xabarni yopish
+func handleDeletedCommand(message *tgbotapi.Message) {
+	key, ok := parseTicketRef(message)
+	if !ok {
+		replyText(message, "❗ Foydalanish: /deleted <GURUH:XABAR yoki havola> (yoki eslatma kartasiga reply)")
+		return
+	}
+	replyText(message, closeDeletedTicket(key, message.From, message.Chat.ID, messageThreadID(message)))
+}
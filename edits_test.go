@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingMessageFirstText(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  PendingMessage
+		want string
+	}{
+		{name: "bitta xabar", msg: PendingMessage{Text: "Salom"}, want: "Salom"},
+		{
+			name: "suhbat",
+			msg:  PendingMessage{Text: "Salom\nviza kerak\nKanadaga", Parts: []MessagePart{{MessageID: 2, Text: "viza kerak"}, {MessageID: 3, Text: "Kanadaga"}}},
+			want: "Salom",
+		},
+		{
+			name: "birinchi xabar faqat fayl",
+			msg:  PendingMessage{Text: "izoh", Parts: []MessagePart{{MessageID: 2, Text: "izoh"}}},
+			want: "",
+		},
+		{
+			name: "keyingi xabar matnsiz",
+			msg:  PendingMessage{Text: "Salom", Parts: []MessagePart{{MessageID: 2}}},
+			want: "Salom",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.firstText(); got != tt.want {
+			t.Errorf("%s: firstText() = %q, kutilgan %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPendingMessageApplyEdit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	conversation := func() *PendingMessage {
+		return &PendingMessage{
+			MessageID: 1,
+			Text:      "Salom\nviza kerak\nKanadaga",
+			Parts:     []MessagePart{{MessageID: 2, Text: "viza kerak"}, {MessageID: 3, Text: "Kanadaga"}},
+			Media:     []MessageMedia{{MessageID: 3, Caption: "Kanadaga"}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		messageID int
+		text      string
+		changed   bool
+		wantText  string
+		wantOld   string
+	}{
+		{name: "birinchi xabar", messageID: 1, text: "Assalomu alaykum", changed: true, wantText: "Assalomu alaykum\nviza kerak\nKanadaga", wantOld: "Salom"},
+		{name: "suhbat o'rtasidagi xabar", messageID: 2, text: "ish vizasi kerak", changed: true, wantText: "Salom\nish vizasi kerak\nKanadaga", wantOld: "viza kerak"},
+		{name: "fayl izohi", messageID: 3, text: "Avstraliyaga", changed: true, wantText: "Salom\nviza kerak\nAvstraliyaga", wantOld: "Kanadaga"},
+		{name: "matn o'zgarmagan", messageID: 2, text: "viza kerak", wantText: "Salom\nviza kerak\nKanadaga"},
+		{name: "boshqa suhbat xabari", messageID: 9, text: "boshqa", wantText: "Salom\nviza kerak\nKanadaga"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := conversation()
+			if changed := msg.applyEdit(tt.messageID, tt.text, now); changed != tt.changed {
+				t.Fatalf("applyEdit = %v, kutilgan %v", changed, tt.changed)
+			}
+			if msg.Text != tt.wantText {
+				t.Errorf("Text = %q, kutilgan %q", msg.Text, tt.wantText)
+			}
+			if !tt.changed {
+				if len(msg.Edits) != 0 {
+					t.Errorf("tarixga %d ta yozuv qo'shildi", len(msg.Edits))
+				}
+				return
+			}
+			want := MessageEdit{MessageID: tt.messageID, OldText: tt.wantOld, EditedAt: now}
+			if len(msg.Edits) != 1 || msg.Edits[0] != want {
+				t.Errorf("Edits = %+v, kutilgan [%+v]", msg.Edits, want)
+			}
+			if tt.messageID == 3 && msg.Media[0].Caption != tt.text {
+				t.Errorf("fayl izohi %q, kutilgan %q", msg.Media[0].Caption, tt.text)
+			}
+		})
+	}
+
+	// Ketma-ket tahrirlar tarixi saqlanadi, firstText yangi matnni qaytaradi
+	msg := conversation()
+	msg.applyEdit(1, "Salom!", now)
+	msg.applyEdit(1, "Salom!!", now.Add(time.Minute))
+	if got := msg.firstText(); got != "Salom!!" {
+		t.Errorf("firstText() = %q, kutilgan %q", got, "Salom!!")
+	}
+	if len(msg.Edits) != 2 || msg.Edits[1].OldText != "Salom!" {
+		t.Errorf("Edits = %+v", msg.Edits)
+	}
+}
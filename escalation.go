@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Eslatma qayerga yuboriladi
+const (
+	TARGET_TOPIC    = "topic"    // Davlat topici
+	TARGET_GENERAL  = "general"  // Admin guruhining umumiy topici
+	TARGET_MANAGERS = "managers" // Menejerlar chati
+)
+
+// Eskalatsiya pog'onasi. After - xabar yuborilgandan beri o'tgan ish vaqti
+// (ish kalendari sozlanmagan bo'lsa oddiy vaqt)
+type EscalationStep struct {
+	After        Duration    `json:"after"`
+	Target       string      `json:"target"`
+	MentionRoles []StaffRole `json:"mention_roles,omitempty"` // Davlatga mas'ul xodimlar shu rollardan belgilanadi
+	MentionUsers []int64     `json:"mention_users,omitempty"`
+}
+
+// Eskalatsiya siyosati. Guruh yoki davlat bo'yicha tanlanadi.
+// Oxirgi pog'onadan keyin Repeat berilgan bo'lsa oxirgi pog'ona shu oraliqda
+// takrorlanadi, aks holda xabar "overdue" deb belgilanadi va eslatmalar to'xtaydi
+type EscalationPolicy struct {
+	Name      string           `json:"name"`
+	Countries []string         `json:"countries,omitempty"`
+	Groups    []int64          `json:"groups,omitempty"`
+	Steps     []EscalationStep `json:"steps"`
+	Repeat    Duration         `json:"repeat,omitempty"`
+}
+
+// AfterHours - ish vaqtidan tashqarida ishlaydigan alohida siyosat (ixtiyoriy).
+// Uning pog'onalari oddiy vaqt bilan, har bir yopiq davr boshidan (xabar shu
+// davrda kelgan bo'lsa - xabar vaqtidan) hisoblanadi va xabarni overdue qilmaydi.
+// Berilmasa ish vaqtidan tashqarida eslatmalar ofis ochilguncha kutadi
+type EscalationData struct {
+	Default    EscalationPolicy   `json:"default"`
+	Policies   []EscalationPolicy `json:"policies"`
+	AfterHours *EscalationPolicy  `json:"after_hours,omitempty"`
+}
+
+// Eskalatsiya siyosatlari jadvali - escalation.json faylida saqlanadi
+type EscalationRegistry struct {
+	mu   sync.RWMutex
+	path string
+	data EscalationData
+}
+
+// Global eskalatsiya jadvali
+var escalation *EscalationRegistry
+
+// Fayl hali yo'q bo'lganda yoziladigan standart siyosat: davlat topici,
+// so'ng mas'ul konsultant belgilanadi, so'ng menejerlar chati, keyin to'xtaydi
+func defaultEscalation(reminderDelay time.Duration) EscalationData {
+	return EscalationData{
+		Default: EscalationPolicy{
+			Name: "default",
+			Steps: []EscalationStep{
+				{After: Duration{reminderDelay}, Target: TARGET_TOPIC},
+				{After: Duration{3 * reminderDelay}, Target: TARGET_TOPIC, MentionRoles: []StaffRole{ROLE_CONSULTANT}},
+				{After: Duration{6 * reminderDelay}, Target: TARGET_MANAGERS, MentionRoles: []StaffRole{ROLE_MANAGER}},
+			},
+		},
+		Policies: []EscalationPolicy{},
+	}
+}
+
+// Siyosatlarni fayldan yuklash. Fayl mavjud bo'lmasa standart siyosat bilan yaratiladi
+func loadEscalation(path string, reminderDelay time.Duration) (*EscalationRegistry, error) {
+	r := &EscalationRegistry{path: path}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.data = defaultEscalation(reminderDelay)
+		data, err := json.MarshalIndent(r.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return nil, err
+		}
+		log.Printf("📁 Eskalatsiya fayli yaratildi: %s", path)
+	} else if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ %d ta eskalatsiya siyosati yuklandi (+ standart)", len(r.data.Policies))
+	return r, nil
+}
+
+// Faylni qayta o'qish
+func (r *EscalationRegistry) Reload() error {
+	raw, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("eskalatsiya faylini o'qib bo'lmadi: %w", err)
+	}
+
+	var data EscalationData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("eskalatsiya fayli noto'g'ri (%s): %w", r.path, err)
+	}
+	if err := data.Default.validate(); err != nil {
+		return fmt.Errorf("standart siyosat: %w", err)
+	}
+	if data.AfterHours != nil {
+		if err := data.AfterHours.validate(); err != nil {
+			return fmt.Errorf("after_hours siyosati: %w", err)
+		}
+	}
+	for _, policy := range data.Policies {
+		if len(policy.Countries) == 0 && len(policy.Groups) == 0 {
+			return fmt.Errorf("%q siyosatida countries ham, groups ham yo'q", policy.Name)
+		}
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("%q siyosati: %w", policy.Name, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.data = data
+	r.mu.Unlock()
+	return nil
+}
+
+func (p EscalationPolicy) validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("kamida bitta pog'ona kerak")
+	}
+	for i, step := range p.Steps {
+		if step.After.Duration <= 0 {
+			return fmt.Errorf("%d-pog'ona: after musbat bo'lishi kerak", i+1)
+		}
+		if i > 0 && step.After.Duration <= p.Steps[i-1].After.Duration {
+			return fmt.Errorf("%d-pog'ona: after oldingisidan katta bo'lishi kerak", i+1)
+		}
+		switch step.Target {
+		case TARGET_TOPIC, TARGET_GENERAL, TARGET_MANAGERS:
+		default:
+			return fmt.Errorf("%d-pog'ona: target topic, general yoki managers bo'lishi kerak: %q", i+1, step.Target)
+		}
+		for _, role := range step.MentionRoles {
+			if _, ok := parseStaffRole(string(role)); !ok {
+				return fmt.Errorf("%d-pog'ona: noma'lum rol %q", i+1, role)
+			}
+		}
+	}
+	if p.Repeat.Duration < 0 {
+		return fmt.Errorf("repeat manfiy bo'lmasligi kerak")
+	}
+	return nil
+}
+
+// Xabar uchun siyosat: avval guruh bo'yicha, keyin davlat bo'yicha, bo'lmasa standart
+func (r *EscalationRegistry) PolicyFor(groupID int64, country string) EscalationPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, policy := range r.data.Policies {
+		for _, id := range policy.Groups {
+			if id == groupID {
+				return policy
+			}
+		}
+	}
+	for _, policy := range r.data.Policies {
+		for _, c := range policy.Countries {
+			if strings.EqualFold(c, country) {
+				return policy
+			}
+		}
+	}
+	return r.data.Default
+}
+
+// Ish vaqtidan tashqari siyosat (sozlanmagan bo'lsa false)
+func (r *EscalationRegistry) AfterHours() (EscalationPolicy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.data.AfterHours == nil {
+		return EscalationPolicy{}, false
+	}
+	return *r.data.AfterHours, true
+}
+
+// Barcha siyosatlar (standart birinchi)
+func (r *EscalationRegistry) List() []EscalationPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := append([]EscalationPolicy{r.data.Default}, r.data.Policies...)
+	if r.data.AfterHours != nil {
+		afterHours := *r.data.AfterHours
+		afterHours.Name = afterHoursPolicyName
+		list = append(list, afterHours)
+	}
+	return list
+}
+
+const afterHoursPolicyName = "after_hours"
+
+// Xabarning navbatdagi pog'onasi. reached - allaqachon yuborilgan pog'onalar
+// soni, elapsed - xabar kutgan vaqt, sinceLast - oxirgi eslatmadan beri.
+// Bir nechta pog'ona vaqti o'tib ketgan bo'lsa (masalan bot o'chiq turgan)
+// faqat eng yuqorisi yuboriladi. Pog'ona indeksi va takrorlash ekanligi qaytariladi
+func (p EscalationPolicy) dueStep(reached int, elapsed, sinceLast time.Duration) (int, bool, bool) {
+	level := -1
+	for i := reached; i < len(p.Steps); i++ {
+		if elapsed >= p.Steps[i].After.Duration {
+			level = i
+		}
+	}
+	if level >= 0 {
+		return level, false, true
+	}
+
+	// Barcha pog'onalar o'tgan - takrorlash (pog'onasiz siyosatda takrorlanadigan pog'ona yo'q)
+	if len(p.Steps) > 0 && reached >= len(p.Steps) && p.Repeat.Duration > 0 && sinceLast >= p.Repeat.Duration {
+		return len(p.Steps) - 1, true, true
+	}
+	return 0, false, false
+}
+
+// Xabarning joriy zinadagi o'rni: yuborilgan pog'onalar soni, kutish vaqti va
+// oxirgi eslatmadan beri o'tgan vaqt. Ish vaqtida asosiy zina ish vaqti bilan
+// hisoblanadi. Ofis yopiq paytda har bir yopiq davr (closedFrom dan boshlab)
+// o'z after_hours zinasini boshidan o'tadi - aks holda birinchi kechada
+// zina tugagach keyingi kechalar va dam olish kunlarida eslatma bo'lmasdi
+func (m *PendingMessage) ladderPosition(open bool, closedFrom, now time.Time) (int, time.Duration, time.Duration) {
+	if open {
+		elapsed := workingElapsed(m.waitingSince(), now)
+		// Hali eslatma bo'lmagan xabar uchun oxirgi eslatmadan beri - kutish vaqtining o'zi
+		sinceLast := elapsed
+		if !m.LastReminder.IsZero() {
+			sinceLast = workingElapsed(m.LastReminder, now)
+		}
+		return m.EscalationLevel, elapsed, sinceLast
+	}
+
+	since := m.waitingSince()
+	if closedFrom.After(since) {
+		since = closedFrom
+	}
+	reached := m.AfterHoursLevel
+	if !m.AfterHoursFrom.Equal(closedFrom) {
+		reached = 0 // Yangi yopiq davr
+	}
+	elapsed := now.Sub(since)
+	sinceLast := elapsed
+	if m.LastReminder.After(since) {
+		sinceLast = now.Sub(m.LastReminder)
+	}
+	return reached, elapsed, sinceLast
+}
+
+// Yuborilgan pog'onani xabarga yozish. Ish vaqtidan tashqari pog'onalar
+// asosiy zinani siljitmaydi va joriy yopiq davrga bog'lanadi
+func (m *PendingMessage) recordStep(policy EscalationPolicy, level int, repeat, open bool, closedFrom, now time.Time) {
+	m.LastReminder = now
+	m.ReminderCount++
+	if repeat {
+		return
+	}
+	if !open {
+		m.AfterHoursLevel = level + 1
+		m.AfterHoursFrom = closedFrom
+		return
+	}
+	m.EscalationLevel = level + 1
+	// Oxirgi pog'ona va takrorlash yo'q - eslatmalar to'xtaydi
+	if m.EscalationLevel >= len(policy.Steps) && policy.Repeat.Duration == 0 {
+		m.Status = "overdue"
+	}
+}
+
+// Pog'onada belgilanadigan xodimlar: aniq ko'rsatilganlar va davlatga mas'ul
+// (davlatlari ro'yxati bo'lmasa - barcha davlatlarga mas'ul) shu roldagi xodimlar
+func escalationMentions(step EscalationStep, country string) []StaffMember {
+	seen := make(map[int64]bool)
+	var result []StaffMember
+	for _, userID := range step.MentionUsers {
+		member, ok := staff.Get(userID)
+		if !ok {
+			member = StaffMember{UserID: userID, Name: strconv.FormatInt(userID, 10)}
+		}
+		seen[userID] = true
+		result = append(result, member)
+	}
+	for _, role := range step.MentionRoles {
+		for _, member := range staff.List() {
+			if member.Role != role || seen[member.UserID] || !member.responsibleFor(country) {
+				continue
+			}
+			seen[member.UserID] = true
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// Pog'ona nishoni: chat va thread
+func escalationTarget(step EscalationStep, country string) (int64, int) {
+	switch step.Target {
+	case TARGET_MANAGERS:
+		if cfg.ManagersChatID != 0 {
+			return cfg.ManagersChatID, cfg.ManagersThreadID
+		}
+		log.Printf("⚠️ managers_chat_id sozlanmagan, eslatma umumiy topicga yuboriladi")
+	case TARGET_TOPIC:
+		// Topic topish yoki admin guruhida yaratish. Eslatma mijoz guruhiga hech qachon yuborilmaydi
+		topic, err := ensureCountryTopic(country)
+		if err == nil {
+			log.Printf("🎯 Topic topildi: %s -> Chat: %d, Thread: %d", country, topic.ChatID, topic.MessageThreadID)
+			return topic.ChatID, topic.MessageThreadID
+		}
+		log.Printf("❌ %v, umumiy topicga yuboriladi", err)
+	}
+	return cfg.AdminChatID, cfg.GeneralThreadID
+}
+
+// /escalation buyrug'i: list, reload
+func handleEscalationCommand(message *tgbotapi.Message) {
+	switch strings.ToLower(strings.TrimSpace(message.CommandArguments())) {
+	case "list":
+		var sb strings.Builder
+		for _, policy := range escalation.List() {
+			fmt.Fprintf(&sb, "📶 %s", policy.Name)
+			if len(policy.Groups) > 0 {
+				fmt.Fprintf(&sb, " (guruhlar: %d ta)", len(policy.Groups))
+			}
+			if len(policy.Countries) > 0 {
+				fmt.Fprintf(&sb, " (%s)", strings.Join(policy.Countries, ", "))
+			}
+			sb.WriteString("\n")
+			for i, step := range policy.Steps {
+				fmt.Fprintf(&sb, "  %d. %s → %s", i+1, step.After.Duration, step.Target)
+				if len(step.MentionRoles) > 0 {
+					roles := make([]string, len(step.MentionRoles))
+					for j, role := range step.MentionRoles {
+						roles[j] = string(role)
+					}
+					fmt.Fprintf(&sb, " (@%s)", strings.Join(roles, ", @"))
+				}
+				sb.WriteString("\n")
+			}
+			if policy.Name == afterHoursPolicyName {
+				sb.WriteString("  🌙 faqat ish vaqtidan tashqarida\n")
+			} else if policy.Repeat.Duration > 0 {
+				fmt.Fprintf(&sb, "  ↻ har %s takrorlanadi\n", policy.Repeat.Duration)
+			} else {
+				sb.WriteString("  ⛔ keyin to'xtaydi (overdue)\n")
+			}
+		}
+		replyText(message, sb.String())
+
+	case "reload":
+		if err := escalation.Reload(); err != nil {
+			log.Printf("❌ Eskalatsiya siyosatlarini qayta yuklashda xato: %v", err)
+			replyText(message, "❌ "+err.Error())
+			return
+		}
+		replyText(message, fmt.Sprintf("🔄 %d ta siyosat qayta yuklandi", len(escalation.List())))
+
+	default:
+		replyText(message, escalationUsage)
+	}
+}
+
+const escalationUsage = `📶 Eskalatsiya buyruqlari:
+/escalation list - barcha siyosatlar
+/escalation reload - escalation.json ni qayta o'qish`
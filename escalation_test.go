@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscalationPolicyDueStep(t *testing.T) {
+	ladder := []EscalationStep{
+		{After: Duration{30 * time.Minute}, Target: TARGET_TOPIC},
+		{After: Duration{2 * time.Hour}, Target: TARGET_GENERAL},
+		{After: Duration{8 * time.Hour}, Target: TARGET_MANAGERS},
+	}
+	withRepeat := EscalationPolicy{Name: "repeat", Steps: ladder, Repeat: Duration{4 * time.Hour}}
+	noRepeat := EscalationPolicy{Name: "once", Steps: ladder}
+	// Konfiguratsiyadan kelmaydi (validate rad etadi) - dueStep baribir xavfsiz bo'lishi kerak
+	noSteps := EscalationPolicy{Name: "empty", Repeat: Duration{time.Hour}}
+
+	for _, policy := range []EscalationPolicy{withRepeat, noRepeat} {
+		if err := policy.validate(); err != nil {
+			t.Fatalf("%q siyosati yaroqsiz: %v", policy.Name, err)
+		}
+	}
+	if err := noSteps.validate(); err == nil {
+		t.Fatal("pog'onasiz siyosat validate dan o'tib ketdi")
+	}
+
+	tests := []struct {
+		name       string
+		policy     EscalationPolicy
+		reached    int
+		elapsed    time.Duration
+		sinceLast  time.Duration
+		wantLevel  int
+		wantRepeat bool
+		wantDue    bool
+	}{
+		{name: "birinchi pog'onadan oldin", policy: withRepeat, elapsed: 29 * time.Minute},
+		{name: "birinchi pog'ona aniq vaqtida", policy: withRepeat, elapsed: 30 * time.Minute, wantLevel: 0, wantDue: true},
+		{name: "birinchi yuborilgan, ikkinchisi hali emas", policy: withRepeat, reached: 1, elapsed: time.Hour, sinceLast: 30 * time.Minute},
+		{name: "ikkinchi pog'ona", policy: withRepeat, reached: 1, elapsed: 2 * time.Hour, wantLevel: 1, wantDue: true},
+		{name: "bot o'chiq turgan - faqat eng yuqorisi", policy: withRepeat, elapsed: 9 * time.Hour, sinceLast: 9 * time.Hour, wantLevel: 2, wantDue: true},
+		{name: "takrorlash vaqti kelmagan", policy: withRepeat, reached: 3, elapsed: 10 * time.Hour, sinceLast: 2 * time.Hour},
+		{name: "takrorlash", policy: withRepeat, reached: 3, elapsed: 12 * time.Hour, sinceLast: 4 * time.Hour, wantLevel: 2, wantRepeat: true, wantDue: true},
+		{name: "takrorlashsiz siyosat to'xtaydi", policy: noRepeat, reached: 3, elapsed: 100 * time.Hour, sinceLast: 100 * time.Hour},
+		{name: "pog'onasiz siyosat", policy: noSteps, elapsed: time.Hour, sinceLast: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, repeat, due := tt.policy.dueStep(tt.reached, tt.elapsed, tt.sinceLast)
+			if due != tt.wantDue {
+				t.Fatalf("due = %v, kutilgan %v", due, tt.wantDue)
+			}
+			if due && (level != tt.wantLevel || repeat != tt.wantRepeat) {
+				t.Errorf("dueStep = (%d, %v), kutilgan (%d, %v)", level, repeat, tt.wantLevel, tt.wantRepeat)
+			}
+		})
+	}
+}
+
+// Ofis ikki kecha yopiq turganda after_hours zinasi har kecha boshidan o'tishi kerak
+func TestAfterHoursLadderRestartsEachClosedPeriod(t *testing.T) {
+	cal, err := newCalendar(&WorkingHoursConfig{
+		Timezone: "Asia/Tashkent",
+		Schedule: map[string]string{"mon": "09:00-18:00", "tue": "09:00-18:00", "wed": "09:00-18:00", "thu": "09:00-18:00", "fri": "09:00-18:00"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := workCalendar
+	t.Cleanup(func() { workCalendar = previous })
+	workCalendar = cal
+
+	daytime := EscalationPolicy{Name: "default", Steps: []EscalationStep{
+		{After: Duration{30 * time.Minute}, Target: TARGET_TOPIC},
+		{After: Duration{2 * time.Hour}, Target: TARGET_GENERAL},
+	}, Repeat: Duration{4 * time.Hour}}
+	afterHours := EscalationPolicy{Name: afterHoursPolicyName, Steps: []EscalationStep{
+		{After: Duration{time.Hour}, Target: TARGET_GENERAL},
+		{After: Duration{3 * time.Hour}, Target: TARGET_MANAGERS},
+	}}
+	for _, policy := range []EscalationPolicy{daytime, afterHours} {
+		if err := policy.validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, cal.loc)
+	}
+	type sent struct {
+		at    time.Time
+		level int
+	}
+
+	// Dushanba 16:00 da kelgan xabar chorshanba ertalabgacha javobsiz qoladi
+	msg := &PendingMessage{Status: "pending", Timestamp: at(19, 16, 0)}
+	var night []sent
+	for now := msg.Timestamp; now.Before(at(21, 9, 0)); now = now.Add(30 * time.Minute) {
+		open := officeOpen(now)
+		policy, closedFrom := daytime, time.Time{}
+		if !open {
+			policy, closedFrom = afterHours, closedSince(now)
+		}
+		reached, elapsed, sinceLast := msg.ladderPosition(open, closedFrom, now)
+		level, repeat, due := policy.dueStep(reached, elapsed, sinceLast)
+		if !due {
+			continue
+		}
+		msg.recordStep(policy, level, repeat, open, closedFrom, now)
+		if !open {
+			night = append(night, sent{now, level})
+		}
+	}
+
+	want := []sent{
+		{at(19, 19, 0), 0}, {at(19, 21, 0), 1}, // Dushanba kechasi
+		{at(20, 19, 0), 0}, {at(20, 21, 0), 1}, // Seshanba kechasi - zina qaytadan
+	}
+	if len(night) != len(want) {
+		t.Fatalf("ish vaqtidan tashqari eslatmalar: %v, kutilgan %v", night, want)
+	}
+	for i := range want {
+		if !night[i].at.Equal(want[i].at) || night[i].level != want[i].level {
+			t.Errorf("%d-eslatma: %v (%d-pog'ona), kutilgan %v (%d-pog'ona)", i+1, night[i].at, night[i].level, want[i].at, want[i].level)
+		}
+	}
+	if msg.Status != "pending" {
+		t.Errorf("holat %q, kutilgan pending", msg.Status)
+	}
+}
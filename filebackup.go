@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102-150405"
+
+// Faylni xavfsiz yozish: vaqtinchalik faylga yozib, fsync qilib, keyin rename.
+// Yozish o'rtasida crash bo'lsa eski fayl butunligicha qoladi
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	// Rename ning o'zi ham diskka yozilishi uchun papkani fsync qilish
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Fayl uchun backup papkasi: fayl yonidagi "backups" papkasi
+func backupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), BACKUP_DIR_NAME)
+}
+
+// Faylning backuplari, eng yangisi birinchi
+func listBackups(path string) []string {
+	pattern := filepath.Join(backupDir(path), filepath.Base(path)+".*.bak")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+	// Nomdagi vaqt formati leksikografik tartiblanadi
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches
+}
+
+// Har bir fayl uchun oxirgi backup vaqti - har yozishda papkani ko'rib
+// chiqmaslik uchun (birinchi marta papkadagi eng yangi backupdan olinadi)
+var (
+	lastBackupMu sync.Mutex
+	lastBackup   = make(map[string]time.Time)
+)
+
+// Backup olish vaqti keldimi. Vaqt kelmagan bo'lsa fayl umuman o'qilmaydi
+func backupDue(path string, every time.Duration, now time.Time) bool {
+	lastBackupMu.Lock()
+	defer lastBackupMu.Unlock()
+
+	last, known := lastBackup[path]
+	if !known {
+		if backups := listBackups(path); len(backups) > 0 {
+			last, _ = backupTime(path, backups[0])
+		}
+		lastBackup[path] = last
+	}
+	return last.IsZero() || now.Sub(last) >= every
+}
+
+// Joriy faylning backup nusxasini olish (every oralig'ida ko'pi bilan bir marta)
+// va eng yangi keep tadan boshqalarini o'chirish
+func rotateBackup(path string, keep int, every time.Duration) {
+	now := time.Now()
+	if !backupDue(path, every, now) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("❌ Backup uchun faylni o'qishda xato (%s): %v", path, err)
+		}
+		return
+	}
+
+	// Buzilgan faylning backupini olmaymiz - u yaxshi backuplarni siqib chiqarmasin
+	if !json.Valid(data) {
+		return
+	}
+
+	if err := os.MkdirAll(backupDir(path), 0755); err != nil {
+		log.Printf("❌ Backup papkasini yaratishda xato: %v", err)
+		return
+	}
+
+	name := filepath.Join(backupDir(path), fmt.Sprintf("%s.%s.bak", filepath.Base(path), now.Format(backupTimeFormat)))
+	if err := writeFileAtomic(name, data, 0644); err != nil {
+		log.Printf("❌ Backup yozishda xato (%s): %v", name, err)
+		return
+	}
+	log.Printf("🗂️ Backup olindi: %s", name)
+
+	lastBackupMu.Lock()
+	lastBackup[path] = now
+	lastBackupMu.Unlock()
+
+	backups := listBackups(path)
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i]); err != nil {
+			log.Printf("❌ Eski backupni o'chirishda xato (%s): %v", backups[i], err)
+		}
+	}
+}
+
+// Backup fayl nomidan vaqtni olish
+func backupTime(path, backup string) (time.Time, bool) {
+	name := strings.TrimPrefix(filepath.Base(backup), filepath.Base(path)+".")
+	name = strings.TrimSuffix(name, ".bak")
+	t, err := time.ParseInLocation(backupTimeFormat, name, time.Local)
+	return t, err == nil
+}
+
+// JSON faylni o'qish. Fayl buzilgan bo'lsa eng yangi yaroqli backupdan
+// tiklanadi: buzilgan fayl ".corrupt-VAQT" nomi bilan chetga olinadi va
+// backup asosiy fayl o'rniga yoziladi. Qaysi manba ishlatilgani qaytariladi
+func readJSONWithBackup(path string, v interface{}) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	parseErr := json.Unmarshal(data, v)
+	if parseErr == nil {
+		return path, nil
+	}
+	log.Printf("❌ JSON parse qilishda xato (%s): %v", path, parseErr)
+
+	for _, backup := range listBackups(path) {
+		backupData, err := ioutil.ReadFile(backup)
+		if err != nil {
+			log.Printf("⚠️ Backupni o'qib bo'lmadi (%s): %v", backup, err)
+			continue
+		}
+		if err := json.Unmarshal(backupData, v); err != nil {
+			log.Printf("⚠️ Backup ham buzilgan (%s): %v", backup, err)
+			continue
+		}
+
+		corrupt := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format(backupTimeFormat))
+		if err := os.Rename(path, corrupt); err != nil {
+			log.Printf("❌ Buzilgan faylni chetga olishda xato: %v", err)
+		} else {
+			log.Printf("🗃️ Buzilgan fayl saqlab qo'yildi: %s", corrupt)
+		}
+		if err := writeFileAtomic(path, backupData, 0644); err != nil {
+			log.Printf("❌ Backupdan tiklangan faylni yozishda xato: %v", err)
+		}
+
+		log.Printf("♻️ %s backupdan tiklandi: %s", path, backup)
+		return backup, nil
+	}
+
+	return "", fmt.Errorf("%s buzilgan va yaroqli backup topilmadi: %w", path, parseErr)
+}
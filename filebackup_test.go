@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for _, content := range []string{`{"a":1}`, `{"a":2}`} {
+		if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("fayl mazmuni %q (%v), kutilgan %q", data, err, content)
+		}
+	}
+	leftovers, _ := filepath.Glob(path + ".tmp-*")
+	if len(leftovers) > 0 {
+		t.Fatalf("vaqtinchalik fayllar qoldi: %v", leftovers)
+	}
+}
+
+func TestReadJSONWithBackup(t *testing.T) {
+	tests := []struct {
+		name       string
+		main       string
+		backups    []string // Eskisidan yangisiga
+		want       int
+		fromBackup bool
+		wantErr    bool
+	}{
+		{name: "yaroqli fayl", main: `{"n":1}`, backups: []string{`{"n":9}`}, want: 1},
+		{name: "buzilgan fayl - eng yangi backup", main: `{"n":`, backups: []string{`{"n":2}`, `{"n":3}`}, want: 3, fromBackup: true},
+		{name: "eng yangi backup ham buzilgan", main: `{"n":`, backups: []string{`{"n":2}`, `{"n"`}, want: 2, fromBackup: true},
+		{name: "yaroqli backup yo'q", main: `{"n":`, backups: []string{`xx`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pending.json")
+			if err := ioutil.WriteFile(path, []byte(tt.main), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(backupDir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+			for i, content := range tt.backups {
+				name := filepath.Join(backupDir(path), "pending.json."+base.Add(time.Duration(i)*time.Minute).Format(backupTimeFormat)+".bak")
+				if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var v struct{ N int }
+			source, err := readJSONWithBackup(path, &v)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("xato kutilgan edi")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.N != tt.want {
+				t.Errorf("N = %d, kutilgan %d", v.N, tt.want)
+			}
+			if (source != path) != tt.fromBackup {
+				t.Errorf("manba %s", source)
+			}
+			if tt.fromBackup {
+				// Asosiy fayl tiklangan, buzilgani chetga olingan
+				var restored struct{ N int }
+				if _, err := readJSONWithBackup(path, &restored); err != nil || restored.N != tt.want {
+					t.Errorf("tiklangan fayl: %+v, %v", restored, err)
+				}
+				if corrupt, _ := filepath.Glob(path + ".corrupt-*"); len(corrupt) != 1 {
+					t.Errorf("buzilgan fayl nusxasi: %v", corrupt)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateBackupInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "groups.json")
+	if err := ioutil.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rotateBackup(path, 3, time.Hour)
+	rotateBackup(path, 3, time.Hour)
+	if n := len(listBackups(path)); n != 1 {
+		t.Fatalf("bir soat ichida %d ta backup, kutilgan 1", n)
+	}
+
+	// Buzilgan faylning backupi olinmaydi
+	other := filepath.Join(filepath.Dir(path), "pending.json")
+	if err := ioutil.WriteFile(other, []byte(`{"a":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rotateBackup(other, 3, 0)
+	if n := len(listBackups(other)); n != 0 {
+		t.Fatalf("buzilgan faylning %d ta backupi olindi", n)
+	}
+}
@@ -2,4 +2,9 @@ module globuz-visa-bot
 
 go 1.21
 
-require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+require (
+	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	go.etcd.io/bbolt v1.3.10
+)
+
+require golang.org/x/sys v0.10.0 // indirect
@@ -1,211 +1,163 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// Konstantalar
-const (
-	BOT_TOKEN         = "8214075520:AAH2NuC9spv7D8up4dFJnW8PariCeSrf0aM"
-	REMINDER_DELAY    = 10 * time.Minute
-	PENDING_JSON_FILE = "pending_messages.json"
-	GROUPS_JSON_FILE  = "groups.json"
-	ADMIN_CHAT_ID     = -1002816907697 // Adminlar guruhi - bu yerdan xabar olmaydi
-)
-
 // Strukturalar
 type PendingMessage struct {
-	MessageID      int       `json:"message_id"`
-	GroupID        int64     `json:"group_id"`
-	GroupTitle     string    `json:"group_title"`
-	UserID         int64     `json:"user_id"`
-	Username       string    `json:"username"`
-	Text           string    `json:"text"`
-	Timestamp      time.Time `json:"timestamp"`
-	LastReminder   time.Time `json:"last_reminder"`
-	ReminderCount  int       `json:"reminder_count"`
-	Status         string    `json:"status"` // "pending", "answered", "ignored"
-	AnsweredBy     int64     `json:"answered_by,omitempty"`
-	AnsweredAt     time.Time `json:"answered_at,omitempty"`
-	SentMessageIDs []int     `json:"sent_message_ids,omitempty"` // Yuborilgan eslatma xabarlar ID lari
-}
-
-type GroupInfo struct {
-	GroupID     int64     `json:"group_id"`
-	GroupTitle  string    `json:"group_title"`
-	GroupType   string    `json:"group_type"`
-	JoinedAt    time.Time `json:"joined_at"`
-	IsActive    bool      `json:"is_active"`
-	AdminIDs    []int64   `json:"admin_ids"`
-	LastUpdated time.Time `json:"last_updated"`
-}
-
-type PendingMessagesData struct {
-	Messages map[string]*PendingMessage `json:"messages"`
+	MessageID       int            `json:"message_id"`
+	GroupID         int64          `json:"group_id"`
+	GroupTitle      string         `json:"group_title"`
+	UserID          int64          `json:"user_id"`
+	Username        string         `json:"username"`
+	Text            string         `json:"text"`
+	Timestamp       time.Time      `json:"timestamp"`
+	LastReminder    time.Time      `json:"last_reminder"`
+	ReminderCount   int            `json:"reminder_count"`
+	Status          string         `json:"status"`                      // "pending", "overdue", "answered", "ignored", "deleted"
+	Country         string         `json:"country,omitempty"`           // Eslatma yo'naltirilgan davlat
+	AfterHoursLevel int            `json:"after_hours_level,omitempty"` // Ish vaqtidan tashqari yuborilgan pog'onalar soni
+	AfterHoursFrom  time.Time      `json:"after_hours_from,omitempty"`  // AfterHoursLevel qaysi yopiq davrga tegishli (davr boshi)
+	EscalationLevel int            `json:"escalation_level,omitempty"`  // Yuborilgan eskalatsiya pog'onalari soni
+	AnsweredBy      int64          `json:"answered_by,omitempty"`
+	AnsweredByName  string         `json:"answered_by_name,omitempty"`
+	AnsweredAt      time.Time      `json:"answered_at,omitempty"`
+	CloseReason     string         `json:"close_reason,omitempty"`     // Bot o'zi yopgan bo'lsa sababi
+	SentMessageIDs  []int          `json:"sent_message_ids,omitempty"` // Eski format: faqat ID lar (yuklashda SentReminders ga o'tkaziladi)
+	SentReminders   []SentReminder `json:"sent_reminders,omitempty"`   // Jonli eslatma kartalari (chat va topic bilan)
+	CardUpdatedAt   time.Time      `json:"card_updated_at,omitempty"`  // Kartalar oxirgi marta yangilangan vaqt
+	ReopenedAt      time.Time      `json:"reopened_at,omitempty"`      // /reopen - eskalatsiya shu vaqtdan hisoblanadi
+	SnoozedUntil    time.Time      `json:"snoozed_until,omitempty"`    // Shu vaqtgacha yangi eslatma yuborilmaydi
+	SnoozedBy       string         `json:"snoozed_by,omitempty"`
+	AssigneeID      int64          `json:"assignee_id,omitempty"` // Xabarni olgan xodim
+	AssigneeName    string         `json:"assignee_name,omitempty"`
+	Assignments     []Assignment   `json:"assignments,omitempty"` // Tayinlash tarixi
+	Parts           []MessagePart  `json:"parts,omitempty"`       // Suhbatdagi keyingi xabarlar (Text ularni ham o'z ichiga oladi)
+	ThreadID        int            `json:"thread_id,omitempty"`   // Mijoz guruhi forum bo'lsa topic ID si
+	Media           []MessageMedia `json:"media,omitempty"`       // Suhbatdagi fayllar (rasm, hujjat, ovozli xabar)
+	Edits           []MessageEdit  `json:"edits,omitempty"`       // Mijoz tahrirlagan matnlar tarixi
 }
 
-type GroupsData struct {
-	Groups map[string]*GroupInfo `json:"groups"`
+// Pending xabar kaliti - Telegram message ID lari faqat bitta chat ichida unikal,
+// shuning uchun guruh ID si ham kalitga kiradi
+type PendingKey struct {
+	GroupID   int64
+	MessageID int
 }
 
-// Topic ma'lumotlari
-type TopicInfo struct {
-	ChatID          int64  `json:"chat_id"`
-	MessageThreadID int    `json:"message_thread_id"`
-	Text            string `json:"text"`
+// JSON fayldagi kalit formati: "GROUPID:MESSAGEID"
+func (k PendingKey) String() string {
+	return fmt.Sprintf("%d:%d", k.GroupID, k.MessageID)
 }
 
-// Global o'zgaruvchilar
-var (
-	bot             *tgbotapi.BotAPI
-	pendingMessages = make(map[int]*PendingMessage)
-	monitoredGroups = make(map[int64]*GroupInfo)
-	topicsList      = []TopicInfo{}
-)
-
-// JSON fayldan pending messages yuklash
-func loadPendingMessages() {
-	if _, err := os.Stat(PENDING_JSON_FILE); os.IsNotExist(err) {
-		log.Printf("📁 Pending messages fayli mavjud emas: %s", PENDING_JSON_FILE)
-		return
+// "GROUPID:MESSAGEID" formatidagi kalitni parse qilish
+func parsePendingKey(s string) (PendingKey, error) {
+	sep := strings.LastIndex(s, ":")
+	if sep < 0 {
+		return PendingKey{}, fmt.Errorf("kalitda ':' yo'q: %q", s)
 	}
-
-	data, err := ioutil.ReadFile(PENDING_JSON_FILE)
+	groupID, err := strconv.ParseInt(s[:sep], 10, 64)
 	if err != nil {
-		log.Printf("❌ Pending messages faylni o'qishda xato: %v", err)
-		return
+		return PendingKey{}, fmt.Errorf("guruh ID noto'g'ri: %q", s)
 	}
-
-	var pendingData PendingMessagesData
-	err = json.Unmarshal(data, &pendingData)
+	msgID, err := strconv.Atoi(s[sep+1:])
 	if err != nil {
-		log.Printf("❌ Pending messages JSON parse qilishda xato: %v", err)
-		return
+		return PendingKey{}, fmt.Errorf("xabar ID noto'g'ri: %q", s)
 	}
-
-	for msgIDStr, msg := range pendingData.Messages {
-		msgID, _ := strconv.Atoi(msgIDStr)
-		pendingMessages[msgID] = msg
-	}
-
-	log.Printf("✅ %d ta javobsiz xabar yuklandi", len(pendingMessages))
+	return PendingKey{GroupID: groupID, MessageID: msgID}, nil
 }
 
-// JSON faylga pending messages saqlash
-func savePendingMessages() {
-	pendingData := PendingMessagesData{
-		Messages: make(map[string]*PendingMessage),
-	}
-
-	for msgID, msg := range pendingMessages {
-		pendingData.Messages[strconv.Itoa(msgID)] = msg
-	}
-
-	data, err := json.MarshalIndent(pendingData, "", "  ")
-	if err != nil {
-		log.Printf("❌ Pending messages JSON yaratishda xato: %v", err)
-		return
-	}
-
-	err = ioutil.WriteFile(PENDING_JSON_FILE, data, 0644)
-	if err != nil {
-		log.Printf("❌ Pending messages faylga yozishda xato: %v", err)
-		return
-	}
+// Pending xabarning kaliti
+func (m *PendingMessage) Key() PendingKey {
+	return PendingKey{GroupID: m.GroupID, MessageID: m.MessageID}
 }
 
-// JSON fayldan guruhlar ma'lumotini yuklash
-func loadGroups() {
-	if _, err := os.Stat(GROUPS_JSON_FILE); os.IsNotExist(err) {
-		log.Printf("📁 Guruhlar fayli mavjud emas: %s", GROUPS_JSON_FILE)
-		return
-	}
-
-	data, err := ioutil.ReadFile(GROUPS_JSON_FILE)
-	if err != nil {
-		log.Printf("❌ Guruhlar faylni o'qishda xato: %v", err)
-		return
-	}
-
-	var groupsData GroupsData
-	err = json.Unmarshal(data, &groupsData)
-	if err != nil {
-		log.Printf("❌ Guruhlar JSON parse qilishda xato: %v", err)
-		return
-	}
-
-	for groupIDStr, group := range groupsData.Groups {
-		groupID, _ := strconv.ParseInt(groupIDStr, 10, 64)
-		monitoredGroups[groupID] = group
-	}
-
-	log.Printf("✅ %d ta guruh ma'lumoti yuklandi", len(monitoredGroups))
+// Xabar hali ochiqmi (javobsiz yoki muddati o'tgan)
+func (m *PendingMessage) isOpen() bool {
+	return m.Status == "pending" || m.Status == "overdue"
 }
 
-// JSON faylga guruhlar ma'lumotini saqlash
-func saveGroups() {
-	groupsData := GroupsData{
-		Groups: make(map[string]*GroupInfo),
+// Eskalatsiya hisoblanadigan vaqt: xabar vaqti yoki qayta ochilgan vaqt
+func (m *PendingMessage) waitingSince() time.Time {
+	if m.ReopenedAt.After(m.Timestamp) {
+		return m.ReopenedAt
 	}
+	return m.Timestamp
+}
 
-	for groupID, group := range monitoredGroups {
-		groupsData.Groups[strconv.FormatInt(groupID, 10)] = group
-	}
+// Pending xabarning mustaqil nusxasi (slice lar ham nusxalanadi)
+func (m *PendingMessage) clone() *PendingMessage {
+	c := *m
+	c.SentMessageIDs = append([]int(nil), m.SentMessageIDs...)
+	c.SentReminders = append([]SentReminder(nil), m.SentReminders...)
+	c.Assignments = append([]Assignment(nil), m.Assignments...)
+	c.Parts = append([]MessagePart(nil), m.Parts...)
+	c.Media = append([]MessageMedia(nil), m.Media...)
+	c.Edits = append([]MessageEdit(nil), m.Edits...)
+	return &c
+}
 
-	data, err := json.MarshalIndent(groupsData, "", "  ")
-	if err != nil {
-		log.Printf("❌ Guruhlar JSON yaratishda xato: %v", err)
-		return
-	}
+type GroupInfo struct {
+	GroupID      int64              `json:"group_id"`
+	GroupTitle   string             `json:"group_title"`
+	GroupType    string             `json:"group_type"`
+	JoinedAt     time.Time          `json:"joined_at"`
+	IsActive     bool               `json:"is_active"`
+	AdminIDs     []int64            `json:"admin_ids"`
+	LinkedChatID int64              `json:"linked_chat_id,omitempty"` // Guruhga bog'langan kanal
+	MigratedTo   int64              `json:"migrated_to,omitempty"`    // Supergroupga aylangan bo'lsa yangi ID
+	MigratedFrom int64              `json:"migrated_from,omitempty"`  // Oddiy guruhdan aylangan bo'lsa eski ID
+	TitleHistory []GroupTitleChange `json:"title_history,omitempty"`
+	LastUpdated  time.Time          `json:"last_updated"`
+}
 
-	err = ioutil.WriteFile(GROUPS_JSON_FILE, data, 0644)
-	if err != nil {
-		log.Printf("❌ Guruhlar faylga yozishda xato: %v", err)
-		return
-	}
+// Guruh ma'lumotining mustaqil nusxasi
+func (g *GroupInfo) clone() *GroupInfo {
+	c := *g
+	c.AdminIDs = append([]int64(nil), g.AdminIDs...)
+	c.TitleHistory = append([]GroupTitleChange(nil), g.TitleHistory...)
+	return &c
+}
 
-	log.Printf("💾 %d ta guruh ma'lumoti saqlandi", len(monitoredGroups))
+// Topic ma'lumotlari
+type TopicInfo struct {
+	ChatID          int64  `json:"chat_id"`
+	MessageThreadID int    `json:"message_thread_id"`
+	Text            string `json:"text"`
 }
 
+// Global o'zgaruvchilar
+var (
+	bot   *tgbotapi.BotAPI
+	state *BotState
+)
+
 // Guruhga qo'shilganda yoki guruh ma'lumotini yangilash
 func updateGroupInfo(chat *tgbotapi.Chat) {
 	if chat.Type != "group" && chat.Type != "supergroup" {
 		return
 	}
 
-	groupInfo, exists := monitoredGroups[chat.ID]
-	if !exists {
-		groupInfo = &GroupInfo{
-			GroupID:    chat.ID,
-			GroupTitle: chat.Title,
-			GroupType:  chat.Type,
-			JoinedAt:   time.Now(),
-			IsActive:   true,
-			AdminIDs:   []int64{},
-		}
-		monitoredGroups[chat.ID] = groupInfo
+	created, oldTitle := state.UpsertGroup(chat)
+	if created {
 		log.Printf("🆕 Yangi guruh qo'shildi: %s (ID: %d)", chat.Title, chat.ID)
+	} else if oldTitle != "" {
+		syncGroupTitle(chat.ID, oldTitle, chat.Title)
 	}
 
-	// Guruh ma'lumotlarini yangilash
-	groupInfo.GroupTitle = chat.Title
-	groupInfo.GroupType = chat.Type
-	groupInfo.LastUpdated = time.Now()
-	groupInfo.IsActive = true
-
 	// Guruh adminlarini olish
 	go updateGroupAdmins(chat.ID)
-
-	saveGroups()
 }
 
 // Guruh adminlarini yangilash
@@ -222,217 +174,181 @@ func updateGroupAdmins(groupID int64) {
 		return
 	}
 
-	if groupInfo, exists := monitoredGroups[groupID]; exists {
-		groupInfo.AdminIDs = []int64{}
-		for _, admin := range admins {
-			groupInfo.AdminIDs = append(groupInfo.AdminIDs, admin.User.ID)
-		}
-		log.Printf("👥 Guruh %d da %d ta admin topildi", groupID, len(groupInfo.AdminIDs))
-		saveGroups()
+	adminIDs := []int64{}
+	for _, admin := range admins {
+		adminIDs = append(adminIDs, admin.User.ID)
 	}
-}
 
-// Admin ekanligini tekshirish (username orqali)
-func isAdminMessage(username string, groupID int64) bool {
-	// Username bo'lmasa false
-	if username == "" {
-		return false
+	// Bog'langan kanal faqat getChat javobida keladi
+	var linkedChatID int64
+	if chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: groupID}}); err != nil {
+		log.Printf("⚠️ Guruh ma'lumotini olishda xato (%d): %v", groupID, err)
+	} else {
+		linkedChatID = chat.LinkedChatID
 	}
 
-	// Username da "globuz" so'zi borligini tekshirish
-	if strings.Contains(strings.ToLower(username), "globuz") {
-		return true
+	if state.UpdateGroup(groupID, func(groupInfo *GroupInfo) {
+		groupInfo.AdminIDs = adminIDs
+		if linkedChatID != 0 {
+			groupInfo.LinkedChatID = linkedChatID
+		}
+	}) {
+		log.Printf("👥 Guruh %d da %d ta admin topildi", groupID, len(adminIDs))
 	}
-
-	return false
 }
 
-// Eslatmalarni tekshirish va yuborish
+// Eslatmalarni tekshirish va yuborish. Har bir xabar o'z eskalatsiya
+// siyosati bo'yicha navbatdagi pog'onaga o'tadi. Faqat ish vaqti hisoblanadi:
+// ofis yopiq paytda eslatmalar ochilguncha kutadi (after_hours siyosati bo'lmasa).
+// Har bir xabarning bitta jonli kartasi bor - u joyida yangilanadi
 func checkAndSendReminders() {
 	now := time.Now()
-	log.Printf("🔍 Eslatmalar tekshirilmoqda... Jami pending: %d", len(pendingMessages))
-
-	for msgID, pendingMsg := range pendingMessages {
-		if pendingMsg.Status != "pending" {
-			continue // Faqat javobsiz xabarlar uchun
-		}
+	log.Printf("🔍 Eslatmalar tekshirilmoqda... Jami pending: %d", state.CountPending())
 
-		timeSinceMessage := now.Sub(pendingMsg.Timestamp)
-		timeSinceLastReminder := now.Sub(pendingMsg.LastReminder)
-
-		shouldSendReminder := false
-
-		if pendingMsg.ReminderCount == 0 && timeSinceMessage >= REMINDER_DELAY {
-			shouldSendReminder = true
-			log.Printf("⏰ Birinchi eslatma vaqti keldi: MSG %d (%v o'tdi)", msgID, timeSinceMessage)
-		} else if pendingMsg.ReminderCount > 0 && timeSinceLastReminder >= REMINDER_DELAY {
-			shouldSendReminder = true
-			log.Printf("⏰ Keyingi eslatma vaqti keldi: MSG %d (%v o'tdi)", msgID, timeSinceLastReminder)
-		}
+	open := officeOpen(now)
+	afterHours, hasAfterHours := escalation.AfterHours()
+	if !open && !hasAfterHours {
+		log.Printf("🌙 Ish vaqti emas - eslatmalar %s gacha kutadi", workCalendar.NextOpen(now).Format("02.01.2006 15:04"))
+		return
+	}
 
-		if shouldSendReminder {
-			log.Printf("📤 Eslatma yuborilmoqda: MSG %d", msgID)
-			sendAdminReminder(pendingMsg)
-			pendingMsg.LastReminder = now
-			pendingMsg.ReminderCount++
-			savePendingMessages()
-		}
+	// Ofis yopiq bo'lsa after_hours zinasi joriy yopiq davr boshidan hisoblanadi
+	var closedFrom time.Time
+	if !open {
+		closedFrom = closedSince(now)
 	}
-}
 
-// Guruh nomidan davlat nomlarini aniqlash
-func extractCountriesFromGroupTitle(groupTitle string) []string {
-	var countries []string
+	// Faqat javobsiz xabarlar nusxalari - yuborish lock dan tashqarida
+	waiting := state.FindPending(func(pendingMsg *PendingMessage) bool {
+		return pendingMsg.Status == "pending"
+	})
 
-	// | belgisi bilan bo'lingan qismlarni tekshirish
-	parts := strings.Split(groupTitle, "|")
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
+	for _, pendingMsg := range waiting {
+		key := pendingMsg.Key()
 
-		// Har bir qismni topiclar bilan solishtirish
-		for _, topic := range topicsList {
-			if strings.EqualFold(part, topic.Text) {
-				countries = append(countries, topic.Text)
-			}
+		country := pendingMsg.Country
+		if country == "" {
+			country = detectCountry(pendingMsg)
 		}
+		policy := escalation.PolicyFor(pendingMsg.GroupID, country)
+		if !open {
+			policy = afterHours
+		}
+		reached, elapsed, sinceLast := pendingMsg.ladderPosition(open, closedFrom, now)
 
-		// # belgisidan keyin davlat nomlarini ham qidirish
-		if strings.HasPrefix(part, "#") {
-			country := strings.TrimPrefix(part, "#")
-			country = strings.TrimSpace(country)
-
-			// Topiclar bilan solishtirish
-			for _, topic := range topicsList {
-				if strings.EqualFold(country, topic.Text) {
-					countries = append(countries, topic.Text)
-				}
+		level, repeat, due := policy.dueStep(reached, elapsed, sinceLast)
+		if due && pendingMsg.snoozed(now) {
+			due = false // Kechiktirilgan - muddat tugagach navbatdagi pog'ona yuboriladi
+		}
+		if !due {
+			// Yangi pog'ona yo'q - faqat kartadagi kutish vaqtini yangilash
+			interval := cfg.CardRefreshInterval.Duration
+			if interval <= 0 || len(pendingMsg.SentReminders) == 0 || now.Sub(pendingMsg.CardUpdatedAt) < interval {
+				continue
+			}
+			// Tekshiruv boshidagi nusxa eskirgan bo'lishi mumkin: shu orada javob
+			// berilgan xabarning "javob berildi" kartasi qayta jonli kartaga aylanmasin
+			current, exists := state.GetPending(key)
+			if !exists || current.Status != "pending" {
+				continue
 			}
+			refreshReminderCards(current)
+			state.UpdatePending(key, func(msg *PendingMessage) bool {
+				msg.CardUpdatedAt = now
+				return false
+			})
+			continue
 		}
-	}
-
-	return countries
-}
-
-// Xabar matnidan davlat nomini topish
-func findCountryInText(text string) string {
-	// Avval xabar matnidan qidirish
-	for _, topic := range topicsList {
-		if strings.Contains(strings.ToLower(text), strings.ToLower(topic.Text)) {
-			return topic.Text
+		step := policy.Steps[level]
+		log.Printf("⏰ Eslatma vaqti keldi: MSG %s, siyosat %q, %d-pog'ona (%v o'tdi)", key, policy.Name, level+1, now.Sub(pendingMsg.Timestamp))
+
+		// Kartada ko'rinadigan yangi holat
+		next := pendingMsg.clone()
+		next.Country = country
+		next.recordStep(policy, level, repeat, open, closedFrom, now)
+
+		log.Printf("📤 Eslatma yuborilmoqda: MSG %s", key)
+		cards, sent := sendAdminReminder(next, step)
+		if !sent {
+			// Pog'ona o'tkazib yuborilmaydi - keyingi tekshiruvda qayta uriniladi
+			continue
 		}
-	}
 
-	return ""
-}
+		stillPending := false
+		current, _ := state.UpdatePending(key, func(msg *PendingMessage) bool {
+			stillPending = msg.Status == "pending"
+			if !stillPending {
+				return false
+			}
+			msg.SentReminders = cards
+			msg.Country = next.Country
+			msg.LastReminder = next.LastReminder
+			msg.CardUpdatedAt = now
+			msg.ReminderCount = next.ReminderCount
+			msg.EscalationLevel = next.EscalationLevel
+			msg.AfterHoursLevel = next.AfterHoursLevel
+			msg.AfterHoursFrom = next.AfterHoursFrom
+			msg.Status = next.Status
+			if msg.Status == "overdue" {
+				log.Printf("⛔ MSG %s muddati o'tdi (overdue) - eslatmalar to'xtatildi", key)
+			}
+			return true
+		})
 
-// Guruh nomidan davlat nomini topish
-func findCountryFromGroupTitle(groupTitle string) string {
-	countries := extractCountriesFromGroupTitle(groupTitle)
-	if len(countries) > 0 {
-		return countries[0]
+		// Yuborish paytida javob berilgan bo'lsa - yangi kartani ham xulosaga aylantirish
+		if !stillPending && current != nil {
+			current.SentReminders = cards
+			resolveSentMessages(current)
+		}
 	}
-	return ""
 }
 
 // Davlat nomi bo'yicha topic ID topish
 func findTopicByCountry(country string) *TopicInfo {
-	for _, topic := range topicsList {
-		if strings.EqualFold(topic.Text, country) {
-			return &topic
-		}
+	if topic, ok := topics.Find(country); ok {
+		return &topic
 	}
 	return nil
 }
 
-// Adminlarga eslatma yuborish - TO'G'RILANGAN VERSIYA
-func sendAdminReminder(pendingMsg *PendingMessage) {
-	log.Printf("🔔 Adminlarga eslatma yuborilmoqda: MSG %d", pendingMsg.MessageID)
-
-	// Avval xabar matnidan davlat nomini topish
-	country := findCountryInText(pendingMsg.Text)
-
-	// Agar xabar matnidan topa olmasa, guruh nomidan qidirish
-	if country == "" {
-		country = findCountryFromGroupTitle(pendingMsg.GroupTitle)
+// Xabar qaysi davlatga tegishli: avval matndan, keyin guruh nomidan,
+// topilmasa "Aniqlanmadi" topici
+func detectCountry(pendingMsg *PendingMessage) string {
+	if country := findCountryInText(pendingMsg.Text); country != "" {
+		return country
 	}
-
-	// Agar hali ham topilmasa
-	if country == "" {
-		country = "Aniqlanmadi"
+	if country := findCountryFromGroupTitle(pendingMsg.GroupTitle); country != "" {
+		return country
 	}
+	return cfg.UnassignedTopic
+}
 
-	// Topic topish
-	topic := findTopicByCountry(country)
-	var targetChatID int64 = pendingMsg.GroupID
-	var targetThreadID int = 0
-
-	if topic != nil {
-		targetChatID = topic.ChatID
-		targetThreadID = topic.MessageThreadID
-		log.Printf("🎯 Topic topildi: %s -> Chat: %d, Thread: %d", country, targetChatID, targetThreadID)
-	} else {
-		log.Printf("❌ Topic topilmadi davlat uchun: %s, asl guruhga yuboriladi", country)
-	}
-
-	reminderText := fmt.Sprintf(`⚠️ JAVOBSIZ XABAR! (%d-ESLATMA)
-
-🏢 Guruh: %s
-📍 Davlat: %s
-👤 Foydalanuvchi: @%s (ID: %d)
-⏰ Xabar vaqti: %s
-📝 Xabar matni: "%s"
-
-🔔 %s dan beri javob kutmoqda!
-⏱️ Jami eslatmalar: %d
-
-Iltimos tezroq javob bering!`,
-		pendingMsg.ReminderCount+1,
-		pendingMsg.GroupTitle,
-		country,
-		pendingMsg.Username,
-		pendingMsg.UserID,
-		pendingMsg.Timestamp.Format("02.01.2006 15:04:05"),
-		pendingMsg.Text,
-		formatDuration(time.Since(pendingMsg.Timestamp)),
-		pendingMsg.ReminderCount)
-
-	// Topicga xabar yuborish
-	msg := tgbotapi.NewMessage(targetChatID, reminderText)
-
-	// Forum topic thread ID ni qo'shish
-	if targetThreadID > 0 {
-		// Qo'lda MessageThreadID ni o'rnatish
-		msgWithThread := tgbotapi.MessageConfig{
-			BaseChat: tgbotapi.BaseChat{
-				ChatID:           targetChatID,
-				ReplyToMessageID: targetThreadID,
-			},
-			Text: reminderText,
+// Adminlarga eskalatsiya pog'onasi bo'yicha eslatma kartasini yuborish
+// yoki yangilash. Xabarning yangilangan kartalar ro'yxati qaytariladi
+func sendAdminReminder(pendingMsg *PendingMessage, step EscalationStep) ([]SentReminder, bool) {
+	log.Printf("🔔 Adminlarga eslatma yuborilmoqda: MSG %d (%s)", pendingMsg.MessageID, step.Target)
+
+	targetChatID, targetThreadID := escalationTarget(step, pendingMsg.Country)
+	cards, sent := deliverReminderCard(pendingMsg, targetChatID, targetThreadID, reminderMentions(pendingMsg, step))
+	if sent {
+		log.Printf("🎯 Eslatma yuborish tugallandi: MSG %d", pendingMsg.MessageID)
+		if cfg.AssigneeDM {
+			notifyAssignee(pendingMsg)
 		}
-		msg = msgWithThread
 	}
+	return cards, sent
+}
 
-	// "Javob berildi" va "Xabarni ko'rish" tugmalarini qo'shish
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Javob berildi", fmt.Sprintf("mark_answered_%d", pendingMsg.MessageID)),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonURL("📄 Xabarni ko'rish", fmt.Sprintf("https://t.me/c/%d/%d", -pendingMsg.GroupID-1000000000000, pendingMsg.MessageID)),
-		),
-	)
-	msg.ReplyMarkup = keyboard
-
-	sentMsg, err := bot.Send(msg)
-	if err != nil {
-		log.Printf("❌ Topicga eslatma yuborishda xato: %v", err)
-	} else {
-		// Yuborilgan xabar ID sini SentMessageIDs ga qo'shish
-		pendingMsg.SentMessageIDs = append(pendingMsg.SentMessageIDs, sentMsg.MessageID)
-		log.Printf("✅ Topic %d ga eslatma yuborildi (MSG ID: %d)", targetThreadID, sentMsg.MessageID)
+// Kartada belgilanadigan xodimlar. Xabarni olgan xodim bo'lsa to'g'ridan-to'g'ri u
+func reminderMentions(pendingMsg *PendingMessage, step EscalationStep) []int64 {
+	if pendingMsg.AssigneeID != 0 {
+		return []int64{pendingMsg.AssigneeID}
 	}
-
-	log.Printf("🎯 Eslatma yuborish tugallandi: MSG %d", pendingMsg.MessageID)
+	var mentions []int64
+	for _, member := range escalationMentions(step, pendingMsg.Country) {
+		mentions = append(mentions, member.UserID)
+	}
+	return mentions
 }
 
 // Vaqt formatini chiroyli ko'rsatish
@@ -451,60 +367,92 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%d soat %d daqiqa", hours, remainingMinutes)
 }
 
-// JSON fayldan topiclar ma'lumotini yuklash
-func loadTopics() {
-	// Hardcoded topics ma'lumotlari
-	topicsList = []TopicInfo{
-		{ChatID: -1002816907697, MessageThreadID: 2, Text: "UK"},
-		{ChatID: -1002816907697, MessageThreadID: 4, Text: "Schengen"},
-		{ChatID: -1002816907697, MessageThreadID: 6, Text: "Australia"},
-		{ChatID: -1002816907697, MessageThreadID: 8, Text: "Japan"},
-		{ChatID: -1002816907697, MessageThreadID: 10, Text: "Peru"},
-		{ChatID: -1002816907697, MessageThreadID: 12, Text: "India"},
-		{ChatID: -1002816907697, MessageThreadID: 14, Text: "Argentina"},
-		{ChatID: -1002816907697, MessageThreadID: 16, Text: "Uganda"},
-		{ChatID: -1002816907697, MessageThreadID: 18, Text: "Kuwait"},
-		{ChatID: -1002816907697, MessageThreadID: 20, Text: "Pakistan"},
-		{ChatID: -1002816907697, MessageThreadID: 22, Text: "Albania"},
-		{ChatID: -1002816907697, MessageThreadID: 24, Text: "Hong Kong"},
-		{ChatID: -1002816907697, MessageThreadID: 26, Text: "Ireland"},
-		{ChatID: -1002816907697, MessageThreadID: 28, Text: "Cyprus"},
-		{ChatID: -1002816907697, MessageThreadID: 30, Text: "Zimbabwe"},
-	}
-
-	log.Printf("✅ %d ta topic yuklandi", len(topicsList))
-}
-
 // Asosiy main funksiya
 func main() {
 	var err error
-	bot, err = tgbotapi.NewBotAPI(BOT_TOKEN)
+	cfg, err = loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	bot, err = tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	bot.Debug = true
+	bot.Debug = cfg.Debug
 	log.Printf("🚀 Bot %s ga ulanildi", bot.Self.UserName)
 
-	// JSON fayllardan ma'lumotlarni yuklash
-	loadPendingMessages()
-	loadGroups()
-	loadTopics()
+	// Store ni ochish va ma'lumotlarni yuklash
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer store.Close()
+	log.Printf("🗄️ Store backend: %s (papka: %s)", cfg.StoreBackend, cfg.DataDir)
 
-	log.Printf("📊 Kuzatilayotgan guruhlar: %d ta", len(monitoredGroups))
-	log.Printf("📋 Mavjud topiclar: %d ta", len(topicsList))
+	state = newBotState(store)
+	if err := state.load(); err != nil {
+		log.Panic(err)
+	}
 
-	// Har 30 soniyada eslatmalarni tekshirish (test uchun)
+	topics, err = loadTopics(cfg.dataPath(cfg.TopicsFile), cfg.AdminChatID)
+	if err != nil {
+		log.Panic(err)
+	}
+	staff, err = loadStaff(cfg.dataPath(cfg.StaffFile))
+	if err != nil {
+		log.Panic(err)
+	}
+	dashboards, err = loadDashboards(cfg.dataPath(cfg.DashboardFile))
+	if err != nil {
+		log.Panic(err)
+	}
+	workCalendar = cfg.calendar
+	if workCalendar != nil {
+		log.Printf("🕘 Ish vaqti kalendari yoqilgan (%s)", cfg.WorkingHours.Timezone)
+	}
+	escalation, err = loadEscalation(cfg.dataPath(cfg.EscalationFile), cfg.ReminderDelay.Duration)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// Anonim admin va bog'langan kanal xabarlari avval mijoz savoli sifatida saqlangan
+	closeServiceAccountTickets()
+
+	// SIGHUP kelganda topiclar va eskalatsiya siyosatlarini qayta yuklash
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := topics.Reload(); err != nil {
+				log.Printf("❌ Topiclarni qayta yuklashda xato: %v", err)
+			} else {
+				log.Printf("🔄 Topiclar qayta yuklandi: %d ta", len(topics.List()))
+			}
+			if err := escalation.Reload(); err != nil {
+				log.Printf("❌ Eskalatsiya siyosatlarini qayta yuklashda xato: %v", err)
+			} else {
+				log.Printf("🔄 Eskalatsiya siyosatlari qayta yuklandi")
+			}
+		}
+	}()
+
+	log.Printf("📊 Kuzatilayotgan guruhlar: %d ta", state.CountGroups())
+	log.Printf("📋 Mavjud topiclar: %d ta", len(topics.List()))
+
+	// Har CheckInterval da eslatmalarni tekshirish
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(cfg.CheckInterval.Duration)
 		defer ticker.Stop()
 
-		log.Printf("⏰ Eslatma timer boshlandi (har 30 soniyada)")
+		log.Printf("⏰ Eslatma timer boshlandi (har %v da)", cfg.CheckInterval.Duration)
 
 		for {
 			select {
 			case <-ticker.C:
 				checkAndSendReminders()
+				refreshDashboards()
 			}
 		}
 	}()
@@ -512,11 +460,13 @@ func main() {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
-	updates := bot.GetUpdatesChan(u)
+	updates := getUpdatesChan(u)
 
 	for update := range updates {
 		if update.Message != nil {
 			handleMessage(update.Message)
+		} else if update.EditedMessage != nil {
+			handleEditedMessage(update.EditedMessage)
 		} else if update.CallbackQuery != nil {
 			handleCallbackQuery(update.CallbackQuery)
 		} else if update.MyChatMember != nil {
@@ -533,10 +483,9 @@ func handleChatMemberUpdate(chatMember *tgbotapi.ChatMemberUpdated) {
 			updateGroupInfo(&chatMember.Chat)
 		} else if chatMember.NewChatMember.Status == "left" || chatMember.NewChatMember.Status == "kicked" {
 			log.Printf("👋 Bot guruhdan chiqarildi: %s (ID: %d)", chatMember.Chat.Title, chatMember.Chat.ID)
-			if groupInfo, exists := monitoredGroups[chatMember.Chat.ID]; exists {
+			state.UpdateGroup(chatMember.Chat.ID, func(groupInfo *GroupInfo) {
 				groupInfo.IsActive = false
-				saveGroups()
-			}
+			})
 		}
 	}
 }
@@ -545,194 +494,249 @@ func handleChatMemberUpdate(chatMember *tgbotapi.ChatMemberUpdated) {
 func handleMessage(message *tgbotapi.Message) {
 	// Guruh xabarlarini tekshirish
 	if message.Chat.Type == "group" || message.Chat.Type == "supergroup" {
-		// Guruh ma'lumotlarini yangilash
+		// Supergroupga aylantirish: eski guruhga keladigan oxirgi xabar
+		if message.MigrateToChatID != 0 {
+			migrateGroup(message.Chat.ID, message.MigrateToChatID)
+			return
+		}
+		// Yangi supergroupdagi birinchi xabar (eski xabar kelmagan bo'lishi mumkin)
+		if message.MigrateFromChatID != 0 {
+			migrateGroup(message.MigrateFromChatID, message.Chat.ID)
+		}
+
+		// Guruh ma'lumotlarini yangilash (nom o'zgargan bo'lsa shu yerda aniqlanadi)
 		updateGroupInfo(message.Chat)
 		handleGroupMessage(message)
 		return
 	}
 
+	// Shaxsiy chatda faqat xodimlar buyruqlari
+	if message.Chat.IsPrivate() && message.IsCommand() {
+		handleAdminCommand(message)
+		return
+	}
+
 	// Private xabarlarni ignore qilish
 	log.Printf("📝 Private xabar e'tiborga olinmadi: %s dan", message.From.FirstName)
 }
 
 // Guruh xabarlarini boshqarish
 func handleGroupMessage(message *tgbotapi.Message) {
-	if message.From.ID == bot.Self.ID {
+	if message.From != nil && message.From.ID == bot.Self.ID {
 		return
 	}
 
 	groupID := message.Chat.ID
 
-	// Admin guruhidan xabar olmaydi
-	if groupID == ADMIN_CHAT_ID {
+	// Admin guruhi (va rahbarlar chati) mijoz guruhi emas: bu yerda faqat
+	// buyruqlar va eslatma kartalariga reply qilingan javoblar
+	if isStaffChat(groupID) {
+		if message.IsCommand() {
+			handleAdminCommand(message)
+			return
+		}
+		if relayStaffReply(message) {
+			return
+		}
 		log.Printf("🚫 Admin guruhidan xabar e'tiborga olinmadi: %s", message.Chat.Title)
 		return
 	}
 
-	username := message.From.UserName
+	sender := classifySender(message)
+	username := sender.Username
 
-	log.Printf("📨 Guruh xabari: %s (@%s) dan %s guruhida (Admin: %v)",
-		message.From.FirstName, username, message.Chat.Title, isAdminMessage(username, groupID))
+	log.Printf("📨 Guruh xabari: %s (@%s, %s) dan %s guruhida (Xodim: %v %s)",
+		sender.Name, username, sender.Kind, message.Chat.Title, sender.IsStaff, sender.Role)
+
+	if sender.Ignored() {
+		log.Printf("🚫 %s e'tiborga olinmadi: MSG %d", sender.Kind, message.MessageID)
+		return
+	}
 
-	// Admin javobini tekshirish
-	if isAdminMessage(username, groupID) {
+	// Admin javobini tekshirish. Kuzatuvchi (viewer) xabari na savol, na javob
+	if sender.IsStaff {
+		if !sender.Role.canAnswer() {
+			return
+		}
 		if message.ReplyToMessage != nil {
 			// Bot tomonidan yuborilgan xabarga javob berilganligini tekshirish
-			if message.ReplyToMessage.From.ID == bot.Self.ID {
+			if reply := message.ReplyToMessage; reply.From != nil && reply.From.ID == bot.Self.ID {
 				// Bot xabarining ID si orqali pending message topish
 				replyToMessageID := message.ReplyToMessage.MessageID
 
-				for _, pendingMsg := range pendingMessages {
-					// Agar bot yuborgan xabar ID si mavjud bo'lsa
-					for _, sentMsgID := range pendingMsg.SentMessageIDs {
-						if sentMsgID == replyToMessageID {
-							pendingMsg.Status = "answered"
-							pendingMsg.AnsweredBy = message.From.ID
-							pendingMsg.AnsweredAt = time.Now()
-
-							log.Printf("✅ Admin bot xabariga javob berdi: Pending MSG %d", pendingMsg.MessageID)
+				// Agar bot yuborgan xabar ID si mavjud bo'lsa
+				matches := state.FindPending(func(pendingMsg *PendingMessage) bool {
+					return pendingMsg.hasReminder(groupID, replyToMessageID)
+				})
+				if len(matches) > 0 {
+					if pendingMsg, ok := state.MarkAnswered(matches[0].Key(), sender.ID, sender.Name); ok {
+						log.Printf("✅ Admin bot xabariga javob berdi: Pending MSG %d", pendingMsg.MessageID)
 
-							// Barcha yuborilgan eslatma xabarlarini o'chirish
-							deleteSentMessages(pendingMsg)
-
-							savePendingMessages()
-							return
-						}
+						// Eslatma kartalarini "javob berildi" holatiga o'tkazish
+						resolveSentMessages(pendingMsg)
 					}
+					return
 				}
 			}
 
-			// Eski usul - oddiy reply
+			// Eski usul - oddiy reply. Suhbatning istalgan xabariga javob butun suhbatni yopadi
 			originalMessageID := message.ReplyToMessage.MessageID
-			if pendingMsg, exists := pendingMessages[originalMessageID]; exists {
-				pendingMsg.Status = "answered"
-				pendingMsg.AnsweredBy = message.From.ID
-				pendingMsg.AnsweredAt = time.Now()
-
-				log.Printf("✅ Admin javob berdi: %d xabarga guruh %d da", originalMessageID, groupID)
+			if key, found := state.FindConversation(groupID, originalMessageID); found {
+				if pendingMsg, exists := state.MarkAnswered(key, sender.ID, sender.Name); exists {
+					log.Printf("✅ Admin javob berdi (%s): %d xabarga guruh %d da (suhbat %s)", sender.Name, originalMessageID, groupID, key)
 
-				// Barcha eslatma xabarlarini o'chirish
-				deleteSentMessages(pendingMsg)
-
-				savePendingMessages()
+					// Eslatma kartalarini "javob berildi" holatiga o'tkazish
+					resolveSentMessages(pendingMsg)
+					return
+				}
 			}
 		}
+
+		// Reply bo'lmagan (yoki hech narsaga mos kelmagan) javob
+		if cfg.AnswerMode == ANSWER_MODE_ANY {
+			answerOpenMessages(message, sender)
+		}
 		return
 	}
 
 	// Oddiy userlarning xabarlarini kuzatish
 	if username == "" {
-		username = message.From.FirstName
+		username = sender.Name
+	}
+
+	// Rasm, hujjat, ovozli xabar va h.k. (matn o'rnida izoh)
+	media := extractMedia(message)
+	if media == nil && messageText(message) == "" {
+		// Xizmat xabarlari: a'zo qo'shildi/chiqdi, pin va h.k.
+		return
+	}
+
+	// Ketma-ket xabarlar bitta suhbatga qo'shiladi
+	if window := cfg.ConversationWindow.Duration; window > 0 {
+		part := MessagePart{MessageID: message.MessageID, Text: messageText(message), Timestamp: time.Now()}
+		if conversation, merged := state.AppendToConversation(groupID, sender.ID, part, media, window); merged {
+			log.Printf("💬 MSG %d suhbatga qo'shildi: %s (%d ta xabar)", message.MessageID, conversation.Key(), len(conversation.Parts)+1)
+			if media != nil {
+				attachMediaToCards(conversation.Key(), *media)
+			}
+			refreshReminderCards(conversation)
+			return
+		}
 	}
 
-	groupInfo := monitoredGroups[groupID]
 	groupTitle := "Noma'lum guruh"
-	if groupInfo != nil {
+	if groupInfo, exists := state.GetGroup(groupID); exists {
 		groupTitle = groupInfo.GroupTitle
 	}
 
 	pendingMsg := &PendingMessage{
-		MessageID:      message.MessageID,
-		GroupID:        groupID,
-		GroupTitle:     groupTitle,
-		UserID:         message.From.ID,
-		Username:       username,
-		Text:           message.Text,
-		Timestamp:      time.Now(),
-		LastReminder:   time.Time{},
-		ReminderCount:  0,
-		Status:         "pending",
-		SentMessageIDs: []int{}, // Yuborilgan eslatma xabar ID lari uchun slice
-	}
-
-	pendingMessages[message.MessageID] = pendingMsg
-	savePendingMessages()
-
-	log.Printf("🔔 Yangi user xabari saqlandi: MSG %d, %s dan %s guruhida", message.MessageID, username, groupTitle)
-}
-
-// Callback query boshqarish
-func handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
-	userID := callback.From.ID
-	data := callback.Data
-
-	bot.Send(tgbotapi.NewCallback(callback.ID, ""))
+		MessageID:     message.MessageID,
+		GroupID:       groupID,
+		GroupTitle:    groupTitle,
+		UserID:        sender.ID,
+		Username:      username,
+		Text:          messageText(message),
+		Timestamp:     time.Now(),
+		LastReminder:  time.Time{},
+		ReminderCount: 0,
+		Status:        "pending",
+		ThreadID:      messageThreadID(message),
+	}
 
-	// Faqat "mark_answered" va "show_message" callback larni boshqarish
-	if strings.HasPrefix(data, "mark_answered_") {
-		msgIDStr := strings.TrimPrefix(data, "mark_answered_")
-		msgID, err := strconv.Atoi(msgIDStr)
-		if err == nil {
-			if pendingMsg, exists := pendingMessages[msgID]; exists {
-				pendingMsg.Status = "answered"
-				pendingMsg.AnsweredBy = userID
-				pendingMsg.AnsweredAt = time.Now()
+	if media != nil {
+		pendingMsg.Media = []MessageMedia{*media}
+	}
 
-				log.Printf("✅ Admin tomonidan javob berildi deb belgilandi: %d xabar", msgID)
+	state.AddPending(pendingMsg)
 
-				// Barcha yuborilgan eslatma xabarlarini o'chirish
-				deleteSentMessages(pendingMsg)
+	log.Printf("🔔 Yangi user xabari saqlandi: MSG %d, %s dan %s guruhida", message.MessageID, username, groupTitle)
+}
 
-				// Callback javobini yuborish
-				bot.Send(tgbotapi.NewCallbackWithAlert(callback.ID, "✅ Xabar javob berildi deb belgilandi va barcha adminlardan o'chirildi!"))
+// "any" rejimi: xodimning guruhdagi xabari shu guruhdagi (answer_same_thread
+// bo'lsa shu topicdagi) barcha ochiq xabarlarni javob berilgan deb yopadi.
+// Juda qisqa matn ("ok", "+") va stikerlar javob hisoblanmaydi, fayl esa matnsiz ham javob
+func answerOpenMessages(message *tgbotapi.Message, sender Sender) {
+	text := strings.TrimSpace(messageText(message))
+	media := extractMedia(message)
+	if (media != nil && media.Kind == KIND_STICKER) || (text == "" && media == nil) {
+		return
+	}
+	if text != "" && utf8.RuneCountInString(text) < cfg.AnswerMinLength {
+		log.Printf("💬 %s xabari javob uchun juda qisqa: MSG %d", sender.Name, message.MessageID)
+		return
+	}
 
-				savePendingMessages()
-			}
+	groupID := message.Chat.ID
+	threadID := messageThreadID(message)
+	open := state.FindPending(func(msg *PendingMessage) bool {
+		if msg.GroupID != groupID || !msg.isOpen() {
+			return false
 		}
-	} else if strings.HasPrefix(data, "show_message_") {
-		// show_message_GROUPID_MESSAGEID formatini parse qilish
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			groupIDStr := parts[2]
-			msgIDStr := parts[3]
-
-			groupID, err1 := strconv.ParseInt(groupIDStr, 10, 64)
-			msgID, err2 := strconv.Atoi(msgIDStr)
-
-			if err1 == nil && err2 == nil {
-				// Message linkini yaratish
-				messageLink := fmt.Sprintf("https://t.me/c/%d/%d", -groupID-1000000000000, msgID)
-
-				if pendingMsg, exists := pendingMessages[msgID]; exists {
-					// Faqat xabar matni va link tugmasi
-					keyboard := tgbotapi.NewInlineKeyboardMarkup(
-						tgbotapi.NewInlineKeyboardRow(
-							tgbotapi.NewInlineKeyboardButtonURL("Xabarni ochish", messageLink),
-						),
-					)
-
-					msg := tgbotapi.NewMessage(callback.Message.Chat.ID, pendingMsg.Text)
-					msg.ReplyMarkup = keyboard
-					bot.Send(msg)
-					bot.Send(tgbotapi.NewCallback(callback.ID, ""))
-				} else {
-					bot.Send(tgbotapi.NewCallback(callback.ID, "Xabar topilmadi"))
-				}
-			}
+		return !cfg.AnswerSameThread || msg.ThreadID == threadID
+	})
+
+	for _, msg := range open {
+		if pendingMsg, ok := state.MarkAnswered(msg.Key(), sender.ID, sender.Name); ok {
+			log.Printf("✅ %s guruhga yozdi, MSG %d javob berilgan deb belgilandi (guruh %d)", sender.Name, pendingMsg.MessageID, groupID)
+			resolveSentMessages(pendingMsg)
 		}
 	}
 }
 
-// Barcha yuborilgan eslatma xabarlarini o'chirish
-func deleteSentMessages(pendingMsg *PendingMessage) {
-	if len(pendingMsg.SentMessageIDs) == 0 {
+// Callback query boshqarish
+func handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
+	data := callback.Data
+
+	// Sahifalash va karta tugmalari callback ga o'zi javob beradi
+	switch {
+	case strings.HasPrefix(data, "page_"):
+		handlePageCallback(callback, strings.TrimPrefix(data, "page_"))
+		return
+	case strings.HasPrefix(data, "deleted_"):
+		handleDeletedCallback(callback, strings.TrimPrefix(data, "deleted_"))
+		return
+	case strings.HasPrefix(data, "ignore_"):
+		handleIgnoreCallback(callback, strings.TrimPrefix(data, "ignore_"))
+		return
+	case strings.HasPrefix(data, "snooze_"):
+		handleSnoozeCallback(callback, strings.TrimPrefix(data, "snooze_"))
+		return
+	case strings.HasPrefix(data, "claim_"):
+		handleClaimCallback(callback, strings.TrimPrefix(data, "claim_"))
+		return
+	case strings.HasPrefix(data, "mark_answered_"):
+		handleMarkAnsweredCallback(callback, strings.TrimPrefix(data, "mark_answered_"))
 		return
 	}
 
-	deletedCount := 0
-	for _, messageID := range pendingMsg.SentMessageIDs {
-		deleteMsg := tgbotapi.NewDeleteMessage(ADMIN_CHAT_ID, messageID)
-		_, err := bot.Request(deleteMsg)
-		if err != nil {
-			log.Printf("❌ Admin guruhdan xabar %d ni o'chirishda xato: %v", messageID, err)
-		} else {
-			deletedCount++
-			log.Printf("🗑️ Admin guruhdan eslatma xabari o'chirildi (MSG ID: %d)", messageID)
+	// Noma'lum tugma - Telegram soat belgisini to'xtatish uchun bo'sh javob
+	bot.Send(tgbotapi.NewCallback(callback.ID, ""))
+}
+
+// "mark_answered_" dan keyingi qismni parse qilish: "GROUPID_MESSAGEID".
+// Eski eslatmalardagi tugmalarda faqat message ID bor - bunday holda
+// xabar ID si bo'yicha yagona mos keladigan pending xabar qidiriladi
+func parseMarkAnsweredData(payload string) (PendingKey, bool) {
+	if sep := strings.LastIndex(payload, "_"); sep > 0 {
+		groupID, err1 := strconv.ParseInt(payload[:sep], 10, 64)
+		msgID, err2 := strconv.Atoi(payload[sep+1:])
+		if err1 != nil || err2 != nil {
+			return PendingKey{}, false
 		}
+		return PendingKey{GroupID: groupID, MessageID: msgID}, true
 	}
 
-	log.Printf("🗑️ Jami %d ta eslatma xabari o'chirildi", deletedCount)
+	msgID, err := strconv.Atoi(payload)
+	if err != nil {
+		return PendingKey{}, false
+	}
 
-	// SentMessageIDs ni tozalash
-	pendingMsg.SentMessageIDs = []int{}
+	found := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.MessageID == msgID
+	})
+	if len(found) != 1 {
+		log.Printf("⚠️ Eski formatdagi tugma: MSG %d uchun %d ta mos xabar topildi", msgID, len(found))
+		return PendingKey{}, false
+	}
+	return found[0].Key(), true
 }
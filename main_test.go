@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParsePendingKey(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PendingKey
+		wantErr bool
+	}{
+		{in: "-1001234567890:42", want: PendingKey{GroupID: -1001234567890, MessageID: 42}},
+		{in: "-5:0", want: PendingKey{GroupID: -5, MessageID: 0}},
+		{in: "12:7", want: PendingKey{GroupID: 12, MessageID: 7}},
+		{in: "-100123", wantErr: true},
+		{in: "abc:42", wantErr: true},
+		{in: "-100:x", wantErr: true},
+		{in: "-100:", wantErr: true},
+		{in: ":42", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePendingKey(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePendingKey(%q) xato = %v, kutilgan xato: %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePendingKey(%q) = %v, kutilgan %v", tt.in, got, tt.want)
+		}
+	}
+
+	// Key().String() va parsePendingKey bir-birini qaytarishi kerak
+	key := PendingKey{GroupID: -1009876543210, MessageID: 314}
+	if got, err := parsePendingKey(key.String()); err != nil || got != key {
+		t.Errorf("parsePendingKey(%q) = %v, %v", key.String(), got, err)
+	}
+}
+
+func TestParseMarkAnsweredData(t *testing.T) {
+	previous := state
+	defer func() { state = previous }()
+	state = newTestState(t)
+
+	now := time.Now()
+	// MSG 7 ikki guruhda bor - eski formatdagi tugma uchun aniqlab bo'lmaydi
+	state.AddPending(&PendingMessage{GroupID: -1, MessageID: 7, Status: "pending", Timestamp: now})
+	state.AddPending(&PendingMessage{GroupID: -2, MessageID: 7, Status: "pending", Timestamp: now})
+	state.AddPending(&PendingMessage{GroupID: -2, MessageID: 8, Status: "pending", Timestamp: now})
+
+	tests := []struct {
+		payload string
+		want    PendingKey
+		ok      bool
+	}{
+		{payload: "-1001234567890_42", want: PendingKey{GroupID: -1001234567890, MessageID: 42}, ok: true},
+		{payload: "-1_7", want: PendingKey{GroupID: -1, MessageID: 7}, ok: true},
+		{payload: "x_7"},
+		{payload: "-1_x"},
+		{payload: "8", want: PendingKey{GroupID: -2, MessageID: 8}, ok: true},
+		{payload: "7"}, // Bir nechta mos xabar
+		{payload: "99"},
+		{payload: "abc"},
+		{payload: ""},
+	}
+	for _, tt := range tests {
+		got, ok := parseMarkAnsweredData(tt.payload)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseMarkAnsweredData(%q) = %v, %v; kutilgan %v, %v", tt.payload, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// Telegram API so'rovi (test uchun yozib olinadi)
+type apiCall struct {
+	Method string
+	Params url.Values
+}
+
+// Soxta Telegram API: har bir so'rov yozib olinadi, getMe dan boshqa
+// barcha metodlarga oddiy xabar qaytariladi. Global bot shu serverga ulanadi
+func newTestBot(t *testing.T) func() []apiCall {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		calls  []apiCall
+		nextID = 1000
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+		mu.Lock()
+		calls = append(calls, apiCall{Method: method, Params: r.Form})
+		nextID++
+		id := nextID
+		mu.Unlock()
+
+		if method == "getMe" {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`)
+			return
+		}
+		chatID := r.Form.Get("chat_id")
+		if chatID == "" {
+			chatID = "0"
+		}
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d,"date":0,"chat":{"id":%s}}}`, id, chatID)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := bot
+	t.Cleanup(func() { bot = previous })
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("test", server.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot = api
+
+	return func() []apiCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]apiCall(nil), calls...)
+	}
+}
+
+// Vaqtinchalik papkadagi xodimlar registri
+func withTestStaff(t *testing.T, members ...StaffMember) {
+	t.Helper()
+	previous := staff
+	t.Cleanup(func() { staff = previous })
+	registry, err := loadStaff(filepath.Join(t.TempDir(), "staff.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range members {
+		if err := registry.Put(member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	staff = registry
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Matndan boshqa xabar turlari
+const (
+	KIND_PHOTO      = "photo"
+	KIND_DOCUMENT   = "document"
+	KIND_VOICE      = "voice"
+	KIND_AUDIO      = "audio"
+	KIND_VIDEO      = "video"
+	KIND_VIDEO_NOTE = "video_note"
+	KIND_ANIMATION  = "animation"
+	KIND_STICKER    = "sticker"
+	KIND_LOCATION   = "location"
+	KIND_CONTACT    = "contact"
+)
+
+// Mijoz yuborgan fayl yoki boshqa matnsiz xabar (pasport rasmi, PDF, ovozli xabar)
+type MessageMedia struct {
+	MessageID int    `json:"message_id"`
+	Kind      string `json:"kind"`
+	Caption   string `json:"caption,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	Duration  int    `json:"duration,omitempty"` // Soniya: ovozli xabar, audio, video
+	Emoji     string `json:"emoji,omitempty"`    // Stiker
+}
+
+// Xabardagi faylni aniqlash. Oddiy matnli xabar uchun nil
+func extractMedia(message *tgbotapi.Message) *MessageMedia {
+	media := &MessageMedia{MessageID: message.MessageID, Caption: message.Caption}
+	switch {
+	case len(message.Photo) > 0:
+		// Eng katta o'lchamdagi rasm oxirida
+		media.Kind = KIND_PHOTO
+		media.FileID = message.Photo[len(message.Photo)-1].FileID
+	case message.Document != nil:
+		media.Kind = KIND_DOCUMENT
+		media.FileID = message.Document.FileID
+		media.FileName = message.Document.FileName
+	case message.Voice != nil:
+		media.Kind = KIND_VOICE
+		media.FileID = message.Voice.FileID
+		media.Duration = message.Voice.Duration
+	case message.Audio != nil:
+		media.Kind = KIND_AUDIO
+		media.FileID = message.Audio.FileID
+		media.FileName = message.Audio.Title
+		if media.FileName == "" {
+			media.FileName = message.Audio.FileName
+		}
+		media.Duration = message.Audio.Duration
+	case message.Video != nil:
+		media.Kind = KIND_VIDEO
+		media.FileID = message.Video.FileID
+		media.FileName = message.Video.FileName
+		media.Duration = message.Video.Duration
+	case message.VideoNote != nil:
+		media.Kind = KIND_VIDEO_NOTE
+		media.FileID = message.VideoNote.FileID
+		media.Duration = message.VideoNote.Duration
+	case message.Animation != nil:
+		media.Kind = KIND_ANIMATION
+		media.FileID = message.Animation.FileID
+		media.FileName = message.Animation.FileName
+	case message.Sticker != nil:
+		media.Kind = KIND_STICKER
+		media.FileID = message.Sticker.FileID
+		media.Emoji = message.Sticker.Emoji
+	case message.Location != nil:
+		media.Kind = KIND_LOCATION
+	case message.Contact != nil:
+		media.Kind = KIND_CONTACT
+		media.FileName = strings.TrimSpace(message.Contact.FirstName + " " + message.Contact.LastName)
+	default:
+		return nil
+	}
+	return media
+}
+
+// Xabarning matni: oddiy matn yoki fayl izohi
+func messageText(message *tgbotapi.Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+	return message.Caption
+}
+
+// Davomiylik "0:42" ko'rinishida
+func formatClock(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Fayl qisqa tavsifi: "📎 passport.pdf", "🎤 voice 0:42"
+func (m MessageMedia) label() string {
+	switch m.Kind {
+	case KIND_PHOTO:
+		return "🖼 rasm"
+	case KIND_DOCUMENT:
+		if m.FileName != "" {
+			return "📎 " + m.FileName
+		}
+		return "📎 hujjat"
+	case KIND_VOICE:
+		return "🎤 voice " + formatClock(m.Duration)
+	case KIND_AUDIO:
+		if m.FileName != "" {
+			return fmt.Sprintf("🎵 %s %s", m.FileName, formatClock(m.Duration))
+		}
+		return "🎵 audio " + formatClock(m.Duration)
+	case KIND_VIDEO:
+		return "🎬 video " + formatClock(m.Duration)
+	case KIND_VIDEO_NOTE:
+		return "📹 video xabar " + formatClock(m.Duration)
+	case KIND_ANIMATION:
+		return "🎞 GIF"
+	case KIND_STICKER:
+		return strings.TrimSpace("🏷 stiker " + m.Emoji)
+	case KIND_LOCATION:
+		return "📍 joylashuv"
+	case KIND_CONTACT:
+		return strings.TrimSpace("👤 kontakt " + m.FileName)
+	}
+	return "📦 " + m.Kind
+}
+
+// Kartadagi xabar mazmuni (HTML): matn va fayllar ro'yxati
+func messageContentHTML(msg *PendingMessage) string {
+	var lines []string
+	if msg.Text != "" || len(msg.Media) == 0 {
+		lines = append(lines, fmt.Sprintf(`📝 Xabar matni: "%s"`, html.EscapeString(truncateText(msg.Text, maxShownTextLen))))
+	}
+	for _, media := range msg.Media {
+		lines = append(lines, html.EscapeString(media.label()))
+	}
+	if n := len(msg.Edits); n > 0 {
+		lines = append(lines, fmt.Sprintf("✏️ Mijoz %d marta tahrirlagan", n))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Xabar mazmuni oddiy matn sifatida (matn bo'lmasa fayllar tavsifi)
+func messagePreview(msg *PendingMessage) string {
+	if msg.Text != "" {
+		return msg.Text
+	}
+	labels := make([]string, 0, len(msg.Media))
+	for _, media := range msg.Media {
+		labels = append(labels, media.label())
+	}
+	if len(labels) == 0 {
+		return "(bo'sh xabar)"
+	}
+	return strings.Join(labels, ", ")
+}
+
+// Mijoz fayllarini eslatma kartasi ostiga (karta reply sifatida) ko'chirish.
+// Ko'chirilgan xabarlar ID lari qaytariladi - ular karta bilan birga o'chiriladi
+func copyMediaToCard(msg *PendingMessage, card SentReminder, media []MessageMedia) []int {
+	var copied []int
+	for _, m := range media {
+		if m.Kind == KIND_STICKER {
+			continue
+		}
+		copiedID, err := copyMessage(CopyMessageConfig{
+			ChatID:           card.ChatID,
+			MessageThreadID:  card.ThreadID,
+			FromChatID:       msg.GroupID,
+			MessageID:        m.MessageID,
+			ReplyToMessageID: card.MessageID,
+		})
+		if err != nil {
+			log.Printf("⚠️ Faylni eslatma ostiga ko'chirib bo'lmadi (%s, MSG %d): %v", msg.Key(), m.MessageID, err)
+			continue
+		}
+		copied = append(copied, copiedID)
+	}
+	return copied
+}
+
+// Karta ostidagi fayl nusxalarini o'chirish (xatolar faqat log qilinadi)
+func deleteAttachments(r SentReminder) {
+	for _, messageID := range r.Attachments {
+		if _, err := bot.Request(tgbotapi.NewDeleteMessage(r.ChatID, messageID)); err != nil && !apiErrorContains(err, "message to delete not found") {
+			log.Printf("⚠️ Fayl nusxasini o'chirib bo'lmadi (Chat: %d, MSG ID: %d): %v", r.ChatID, messageID, err)
+		}
+	}
+}
+
+// Suhbatga keyin qo'shilgan faylni mavjud kartalar ostiga ko'chirish
+func attachMediaToCards(key PendingKey, media MessageMedia) {
+	msg, exists := state.GetPending(key)
+	if !exists || len(msg.SentReminders) == 0 {
+		return
+	}
+
+	copied := make(map[int][]int)
+	for _, card := range msg.SentReminders {
+		if ids := copyMediaToCard(msg, card, []MessageMedia{media}); len(ids) > 0 {
+			copied[card.MessageID] = ids
+		}
+	}
+	if len(copied) == 0 {
+		return
+	}
+
+	state.UpdatePending(key, func(m *PendingMessage) bool {
+		for i := range m.SentReminders {
+			m.SentReminders[i].Attachments = append(m.SentReminders[i].Attachments, copied[m.SentReminders[i].MessageID]...)
+		}
+		return true
+	})
+}
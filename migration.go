@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Guruh nomi o'zgarishi tarixi
+type GroupTitleChange struct {
+	OldTitle  string    `json:"old_title"`
+	NewTitle  string    `json:"new_title"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Oddiy guruh supergroupga aylantirilganda eski ID ishlamay qoladi:
+// guruh va uning barcha xabarlari yangi ID ga o'tkaziladi, ochiq xabarlar
+// kartalari (tugmalar va havolalar yangi ID bilan) qayta chiziladi
+func migrateGroup(oldID, newID int64) {
+	moved := state.MigrateGroup(oldID, newID)
+	log.Printf("🔀 Guruh %d -> %d supergroupga aylandi: %d ta xabar yangi ID ga o'tkazildi", oldID, newID, len(moved))
+
+	for _, policy := range escalation.List() {
+		for _, id := range policy.Groups {
+			if id == oldID {
+				log.Printf("⚠️ escalation.json dagi %q siyosatida eski guruh ID si (%d) bor - %d ga almashtiring", policy.Name, oldID, newID)
+			}
+		}
+	}
+
+	for _, msg := range moved {
+		if msg.isOpen() {
+			refreshReminderCards(msg)
+		}
+	}
+}
+
+// Guruh nomi o'zgardi: ochiq xabarlardagi nom yangilanadi. Davlat matndan
+// emas, guruh nomidan aniqlangan bo'lsa u ham qaytadan aniqlanadi va eski
+// davlat topicidagi karta yangi davlat topiciga ko'chiriladi
+func syncGroupTitle(groupID int64, oldTitle, newTitle string) {
+	log.Printf("✏️ Guruh nomi o'zgardi (ID: %d): %q -> %q", groupID, oldTitle, newTitle)
+
+	open := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.GroupID == groupID && msg.isOpen()
+	})
+	for _, msg := range open {
+		updated, exists := state.UpdatePending(msg.Key(), func(m *PendingMessage) bool {
+			m.GroupTitle = newTitle
+			if m.Country != "" && findCountryInText(m.Text) == "" {
+				m.Country = detectCountry(m)
+			}
+			return true
+		})
+		if !exists {
+			continue
+		}
+		if updated.Country != msg.Country {
+			log.Printf("📍 %s davlati guruh nomi bo'yicha o'zgardi: %s -> %s", msg.Key(), msg.Country, updated.Country)
+			if cards, moved := moveCountryCard(updated, msg.Country); moved {
+				stillOpen := false
+				current, _ := state.UpdatePending(updated.Key(), func(m *PendingMessage) bool {
+					stillOpen = m.isOpen()
+					if !stillOpen {
+						return false
+					}
+					m.SentReminders = cards
+					return true
+				})
+				// Ko'chirish paytida javob berilgan bo'lsa - yangi kartani ham xulosaga aylantirish
+				if !stillOpen {
+					if current != nil {
+						current.SentReminders = cards
+						resolveSentMessages(current)
+					}
+					continue
+				}
+				updated = current
+			}
+		}
+		refreshReminderCards(updated)
+	}
+}
+
+// Eski davlat topicidagi kartani yangi davlat topiciga ko'chirish: yangi
+// topicga karta yuboriladi (xodimlar belgilanadi), eskisi o'chiriladi.
+// Umumiy topic va rahbarlar chatidagi kartalar joyida qoladi.
+// Xabarning yangilangan kartalar ro'yxati qaytariladi
+func moveCountryCard(msg *PendingMessage, oldCountry string) ([]SentReminder, bool) {
+	oldTopic, ok := topics.Find(oldCountry)
+	if !ok {
+		return msg.SentReminders, false
+	}
+	stale := -1
+	for i, card := range msg.SentReminders {
+		if card.targets(oldTopic.ChatID, oldTopic.MessageThreadID) {
+			stale = i
+		}
+	}
+	if stale < 0 {
+		return msg.SentReminders, false
+	}
+
+	step := EscalationStep{Target: TARGET_TOPIC}
+	chatID, threadID := escalationTarget(step, msg.Country)
+	if msg.SentReminders[stale].targets(chatID, threadID) {
+		return msg.SentReminders, false
+	}
+
+	// Eski karta ro'yxatda qolsa, yangi topic umumiy topicga tushganda u tahrirlanib qolardi
+	rest := msg.clone()
+	rest.SentReminders = append(append([]SentReminder(nil), msg.SentReminders[:stale]...), msg.SentReminders[stale+1:]...)
+	cards, sent := deliverReminderCard(rest, chatID, threadID, reminderMentions(msg, step))
+	if !sent {
+		return msg.SentReminders, false
+	}
+	if err := deleteReminder(msg.SentReminders[stale]); err != nil {
+		log.Printf("⚠️ Eski davlat topicidagi kartani o'chirib bo'lmadi: %v", err)
+	}
+	log.Printf("🔀 MSG %s kartasi %s topicidan %s topiciga ko'chirildi", msg.Key(), oldCountry, msg.Country)
+	return cards, true
+}
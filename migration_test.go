@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Guruh nomi boshqa davlatga o'zgarsa karta eski davlat topicida qolmasligi kerak
+func TestSyncGroupTitleMovesCountryCard(t *testing.T) {
+	withDefaultTopics(t)
+	cfg.AdminChatID = -100
+	previous := state
+	t.Cleanup(func() { state = previous })
+	state = newTestState(t)
+	calls := newTestBot(t)
+	withTestStaff(t)
+
+	const (
+		japanThread = 8
+		ukThread    = 2
+		topicCardID = 40
+		generalCard = 41
+	)
+	key := PendingKey{GroupID: -500, MessageID: 7}
+	state.AddPending(&PendingMessage{
+		GroupID:    key.GroupID,
+		MessageID:  key.MessageID,
+		GroupTitle: "Japan mijozlar",
+		Country:    "Japan",
+		Text:       "Salom, hujjatlar tayyormi?",
+		Status:     "pending",
+		Timestamp:  time.Now().Add(-3 * time.Hour),
+		SentReminders: []SentReminder{
+			{ChatID: -100, ThreadID: japanThread, MessageID: topicCardID},
+			{ChatID: -100, MessageID: generalCard},
+		},
+	})
+
+	syncGroupTitle(key.GroupID, "Japan mijozlar", "UK mijozlar")
+
+	msg, ok := state.GetPending(key)
+	if !ok {
+		t.Fatal("xabar topilmadi")
+	}
+	if msg.Country != "UK" {
+		t.Fatalf("davlat %q, kutilgan UK", msg.Country)
+	}
+
+	var inUK, general int
+	for _, card := range msg.SentReminders {
+		switch {
+		case card.targets(-100, japanThread):
+			t.Errorf("Japan topicidagi karta qolib ketdi: %+v", card)
+		case card.targets(-100, ukThread):
+			inUK++
+		case card.MessageID == generalCard:
+			general++
+		}
+	}
+	if inUK != 1 || general != 1 {
+		t.Errorf("kartalar %+v: UK topicida %d, umumiy topicda %d; kutilgan 1 va 1", msg.SentReminders, inUK, general)
+	}
+
+	var sentToUK, deletedOld bool
+	for _, call := range calls() {
+		switch call.Method {
+		case "sendMessage":
+			sentToUK = sentToUK || call.Params.Get("message_thread_id") == "2"
+		case "deleteMessage":
+			deletedOld = deletedOld || call.Params.Get("message_id") == "40"
+		}
+	}
+	if !sentToUK {
+		t.Error("UK topiciga karta yuborilmadi")
+	}
+	if !deletedOld {
+		t.Error("Japan topicidagi eski karta o'chirilmadi")
+	}
+}
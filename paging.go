@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Bir sahifadagi qatorlar soni
+const pageSize = 10
+
+// Xotirada saqlanadigan ro'yxatlar soni - eskilari o'chiriladi
+const maxListSessions = 200
+
+// Sahifalangan ro'yxat: har sahifa ochilganda qatorlar qaytadan quriladi,
+// shuning uchun ro'yxat doim joriy holatni ko'rsatadi
+type listSession struct {
+	title string
+	build func() []string
+}
+
+var (
+	listSessionsMu sync.Mutex
+	listSessions   = make(map[string]listSession)
+	listOrder      []string
+)
+
+func listSessionKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
+
+func rememberList(key string, session listSession) {
+	listSessionsMu.Lock()
+	defer listSessionsMu.Unlock()
+
+	listSessions[key] = session
+	listOrder = append(listOrder, key)
+	for len(listOrder) > maxListSessions {
+		delete(listSessions, listOrder[0])
+		listOrder = listOrder[1:]
+	}
+}
+
+func findList(key string) (listSession, bool) {
+	listSessionsMu.Lock()
+	defer listSessionsMu.Unlock()
+
+	session, ok := listSessions[key]
+	return session, ok
+}
+
+// Sahifa matni va tugmalari
+func renderPage(session listSession, page int) (string, tgbotapi.InlineKeyboardMarkup, bool) {
+	lines := session.build()
+	pages := (len(lines) + pageSize - 1) / pageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%d ta)\n\n", session.title, len(lines))
+	if len(lines) == 0 {
+		sb.WriteString("Bo'sh")
+	}
+	end := page*pageSize + pageSize
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, line := range lines[page*pageSize : end] {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	if pages == 1 {
+		return sb.String(), tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	fmt.Fprintf(&sb, "\n📄 %d/%d sahifa", page+1, pages)
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Oldingi", fmt.Sprintf("page_%d", page-1)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData("🔄", fmt.Sprintf("page_%d", page)))
+	if page < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Keyingi ➡️", fmt.Sprintf("page_%d", page+1)))
+	}
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(row), true
+}
+
+// Ro'yxatni birinchi sahifasi bilan yuborish (HTML)
+func sendPagedList(message *tgbotapi.Message, title string, build func() []string) {
+	session := listSession{title: title, build: build}
+	text, keyboard, paged := renderPage(session, 0)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	if paged {
+		msg.ReplyMarkup = keyboard
+	}
+	sent, err := bot.Send(msg)
+	if err != nil {
+		log.Printf("❌ Ro'yxatni yuborishda xato: %v", err)
+		return
+	}
+	if paged {
+		rememberList(listSessionKey(sent.Chat.ID, sent.MessageID), session)
+	}
+}
+
+// "page_N" tugmasi: ro'yxat xabarini shu sahifa bilan tahrirlash
+func handlePageCallback(callback *tgbotapi.CallbackQuery, payload string) {
+	if callback.Message == nil {
+		return
+	}
+	page, err := strconv.Atoi(payload)
+	if err != nil {
+		return
+	}
+
+	session, ok := findList(listSessionKey(callback.Message.Chat.ID, callback.Message.MessageID))
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, "Ro'yxat eskirgan - buyruqni qayta yuboring"))
+		return
+	}
+
+	text, keyboard, _ := renderPage(session, page)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.DisableWebPagePreview = true
+	if _, err := bot.Request(edit); err != nil && !apiErrorContains(err, "message is not modified") {
+		log.Printf("❌ Sahifani ko'rsatishda xato: %v", err)
+	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+}
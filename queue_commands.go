@@ -0,0 +1,419 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// https://t.me/c/1234567890/45 ko'rinishidagi xabar havolasi. Forum guruhida
+// havola https://t.me/c/1234567890/<topic>/45 bo'ladi - oxirgi son xabar ID si
+var messageLinkPattern = regexp.MustCompile(`t\.me/c/(\d+)/(?:\d+/)?(\d+)`)
+
+// Xabar havolasidan kalit olish
+func parseMessageLink(link string) (PendingKey, bool) {
+	m := messageLinkPattern.FindStringSubmatch(link)
+	if m == nil {
+		return PendingKey{}, false
+	}
+	internalID, err1 := strconv.ParseInt(m[1], 10, 64)
+	messageID, err2 := strconv.Atoi(m[2])
+	if err1 != nil || err2 != nil {
+		return PendingKey{}, false
+	}
+	return PendingKey{GroupID: -internalID - 1000000000000, MessageID: messageID}, true
+}
+
+// Buyruqdagi xabar identifikatori: "GURUH:XABAR" kaliti, xabar havolasi
+// yoki (argument bo'lmasa) reply qilingan eslatma kartasi
+func parseTicketRef(message *tgbotapi.Message) (PendingKey, bool) {
+	arg := strings.TrimSpace(message.CommandArguments())
+	if arg != "" {
+		arg = strings.Fields(arg)[0]
+		if key, err := parsePendingKey(arg); err == nil {
+			return key, true
+		}
+		return parseMessageLink(arg)
+	}
+
+	reply := message.ReplyToMessage
+	if reply == nil {
+		return PendingKey{}, false
+	}
+	matches := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.hasReminder(reply.Chat.ID, reply.MessageID)
+	})
+	if len(matches) == 0 {
+		return PendingKey{}, false
+	}
+	return matches[0].Key(), true
+}
+
+// Ro'yxatdagi bitta xabar qatori
+func pendingLine(msg *PendingMessage, now time.Time) string {
+	icon := "🔸"
+	if msg.Status == "overdue" {
+		icon = "⛔"
+	} else if msg.snoozed(now) {
+		icon = "💤"
+	}
+	assignee := ""
+	if msg.AssigneeID != 0 {
+		assignee = " — 🙋 " + html.EscapeString(msg.AssigneeName)
+	}
+	return fmt.Sprintf(`%s <code>%s</code> %s — @%s — %s — %s%s — <a href="%s">ochish</a>`,
+		icon, msg.Key(), html.EscapeString(msg.GroupTitle), html.EscapeString(msg.Username),
+		html.EscapeString(msg.Country), formatAge(now.Sub(msg.Timestamp)), assignee, messageLink(msg))
+}
+
+// /pending [davlat|guruh] - javobsiz xabarlar (eng eskisi birinchi)
+func handlePendingCommand(message *tgbotapi.Message) {
+	filter := strings.TrimSpace(message.CommandArguments())
+	title := "⏳ Javobsiz xabarlar"
+	if filter != "" {
+		title += ": " + html.EscapeString(filter)
+	}
+
+	sendPagedList(message, title, func() []string {
+		now := time.Now()
+		waiting := state.FindPending(func(msg *PendingMessage) bool {
+			return msg.isOpen()
+		})
+
+		var lines []string
+		for _, msg := range waiting {
+			if msg.Country == "" {
+				msg.Country = detectCountry(msg)
+			}
+			if strings.EqualFold(filter, "mine") {
+				if msg.AssigneeID != message.From.ID {
+					continue
+				}
+			} else if filter != "" && !pendingMatchesFilter(msg, filter) {
+				continue
+			}
+			lines = append(lines, pendingLine(msg, now))
+		}
+		return lines
+	})
+}
+
+// Filtr: davlat nomi, guruh ID si yoki guruh nomining bir qismi
+func pendingMatchesFilter(msg *PendingMessage, filter string) bool {
+	if strings.EqualFold(msg.Country, filter) {
+		return true
+	}
+	if id, err := strconv.ParseInt(filter, 10, 64); err == nil {
+		return msg.GroupID == id
+	}
+	return strings.Contains(strings.ToLower(msg.GroupTitle), strings.ToLower(filter))
+}
+
+// /ignore <id> - javob shart emas deb belgilash
+func handleIgnoreCommand(message *tgbotapi.Message) {
+	key, ok := parseTicketRef(message)
+	if !ok {
+		replyText(message, "❗ Foydalanish: /ignore <GURUH:XABAR yoki havola> (yoki eslatma kartasiga reply)")
+		return
+	}
+
+	msg, ok := state.GetPending(key)
+	if !ok {
+		replyText(message, fmt.Sprintf("❓ %s xabari topilmadi", key))
+		return
+	}
+	if !msg.isOpen() {
+		replyText(message, fmt.Sprintf("ℹ️ %s allaqachon yopilgan (%s)", key, msg.Status))
+		return
+	}
+
+	closed, ok := state.CloseTicket(key, "ignored", message.From.ID, displayName(message.From))
+	if !ok {
+		return
+	}
+	log.Printf("🙈 %s javob shart emas deb belgilandi (%s)", key, displayName(message.From))
+	resolveSentMessages(closed)
+	replyText(message, fmt.Sprintf("🙈 %s - javob shart emas deb belgilandi", key))
+}
+
+// /reopen <id> - yopilgan yoki muddati o'tgan xabarni qayta ochish.
+// Eskalatsiya zinasi boshidan, qayta ochilgan vaqtdan hisoblanadi
+func handleReopenCommand(message *tgbotapi.Message) {
+	key, ok := parseTicketRef(message)
+	if !ok {
+		replyText(message, "❗ Foydalanish: /reopen <GURUH:XABAR yoki havola>")
+		return
+	}
+
+	msg, ok := state.GetPending(key)
+	if !ok {
+		replyText(message, fmt.Sprintf("❓ %s xabari topilmadi", key))
+		return
+	}
+	if msg.Status == "pending" {
+		replyText(message, fmt.Sprintf("ℹ️ %s allaqachon ochiq", key))
+		return
+	}
+
+	reopened, ok := state.Reopen(key)
+	if !ok {
+		return
+	}
+	// Muddati o'tgan xabarning eski kartalari yopiladi - yangi zina yangi karta ochadi
+	closed := reopened.clone()
+	closed.SentReminders = msg.SentReminders
+	deleteSentMessages(closed)
+
+	log.Printf("🔁 %s qayta ochildi (%s)", key, displayName(message.From))
+	replyText(message, fmt.Sprintf("🔁 %s qayta ochildi - eslatmalar boshidan boshlanadi", key))
+}
+
+// Statistika davri: today/bugun, 7d, 30d yoki Go formatidagi vaqt (24h)
+func parseStatsPeriod(arg string, now time.Time) (time.Time, string, bool) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	switch arg {
+	case "":
+		return now.AddDate(0, 0, -7), "oxirgi 7 kun", true
+	case "today", "bugun":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), "bugun", true
+	}
+	if strings.HasSuffix(arg, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(arg, "d")); err == nil && days > 0 {
+			return now.AddDate(0, 0, -days), fmt.Sprintf("oxirgi %d kun", days), true
+		}
+	}
+	if d, err := time.ParseDuration(arg); err == nil && d > 0 {
+		return now.Add(-d), "oxirgi " + formatAge(d), true
+	}
+	return time.Time{}, "", false
+}
+
+// /stats [davr] - davr ichida kelgan xabarlar bo'yicha statistika
+func handleStatsCommand(message *tgbotapi.Message) {
+	now := time.Now()
+	since, label, ok := parseStatsPeriod(message.CommandArguments(), now)
+	if !ok {
+		replyText(message, "❗ Foydalanish: /stats [today|7d|30d|24h]")
+		return
+	}
+
+	// Yopilgan xabarlar faqat store da - statistika store so'rovidan olinadi
+	messages, err := state.QueryPending(PendingQuery{NewerThan: since})
+	if err != nil {
+		log.Printf("❌ Statistika uchun xabarlarni o'qishda xato: %v", err)
+		replyText(message, "❌ Xabarlarni o'qib bo'lmadi")
+		return
+	}
+
+	statuses := make(map[string]int)
+	countries := make(map[string]int)
+	responders := make(map[string]int)
+	var responseTimes []time.Duration
+	for _, msg := range messages {
+		statuses[msg.Status]++
+		country := msg.Country
+		if country == "" {
+			country = detectCountry(msg)
+		}
+		countries[country]++
+		if msg.Status == "answered" {
+			responseTimes = append(responseTimes, msg.AnsweredAt.Sub(msg.Timestamp))
+			name := msg.AnsweredByName
+			if name == "" {
+				name = strconv.FormatInt(msg.AnsweredBy, 10)
+			}
+			responders[name]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📈 <b>Statistika: %s</b>\n\n", label)
+	fmt.Fprintf(&sb, "📨 Jami xabarlar: %d\n", len(messages))
+	fmt.Fprintf(&sb, "✅ Javob berilgan: %d\n", statuses["answered"])
+	fmt.Fprintf(&sb, "🙈 Javob shart emas: %d\n", statuses["ignored"])
+	fmt.Fprintf(&sb, "🗑️ O'chirilgan: %d\n", statuses["deleted"])
+	fmt.Fprintf(&sb, "⏳ Javobsiz: %d\n", statuses["pending"])
+	fmt.Fprintf(&sb, "⛔ Muddati o'tgan: %d\n", statuses["overdue"])
+
+	if len(responseTimes) > 0 {
+		sort.Slice(responseTimes, func(i, j int) bool { return responseTimes[i] < responseTimes[j] })
+		var total time.Duration
+		for _, d := range responseTimes {
+			total += d
+		}
+		fmt.Fprintf(&sb, "\n⏱️ O'rtacha javob vaqti: %s\n", formatAge(total/time.Duration(len(responseTimes))))
+		fmt.Fprintf(&sb, "⏱️ Median: %s\n", formatAge(responseTimes[len(responseTimes)/2]))
+		fmt.Fprintf(&sb, "⏱️ Eng uzoq: %s\n", formatAge(responseTimes[len(responseTimes)-1]))
+	}
+
+	if top := topCounts(countries, 5); len(top) > 0 {
+		sb.WriteString("\n📍 Davlatlar:\n")
+		for _, line := range top {
+			sb.WriteString(line)
+		}
+	}
+	if top := topCounts(responders, 5); len(top) > 0 {
+		sb.WriteString("\n🙋 Eng faol xodimlar:\n")
+		for _, line := range top {
+			sb.WriteString(line)
+		}
+	}
+	replyHTML(message, sb.String())
+}
+
+// Eng ko'p uchraganlar (kamayish tartibida) - "• nom: son" qatorlari
+func topCounts(counts map[string]int, limit int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > limit {
+		names = names[:limit]
+	}
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("• %s: %d\n", html.EscapeString(name), counts[name])
+	}
+	return lines
+}
+
+// /groups - kuzatilayotgan guruhlar va ulardagi javobsiz xabarlar soni
+func handleGroupsCommand(message *tgbotapi.Message) {
+	sendPagedList(message, "🏢 Guruhlar", func() []string {
+		open := make(map[int64]int)
+		for _, msg := range state.FindPending(func(msg *PendingMessage) bool { return msg.isOpen() }) {
+			open[msg.GroupID]++
+		}
+
+		groups := state.ListGroups()
+		sort.Slice(groups, func(i, j int) bool {
+			if groups[i].IsActive != groups[j].IsActive {
+				return groups[i].IsActive
+			}
+			return groups[i].GroupTitle < groups[j].GroupTitle
+		})
+
+		lines := make([]string, 0, len(groups))
+		for _, group := range groups {
+			icon := "🟢"
+			if !group.IsActive {
+				icon = "⚪"
+			}
+			line := fmt.Sprintf("%s %s (<code>%d</code>)", icon, html.EscapeString(group.GroupTitle), group.GroupID)
+			if group.MigratedTo != 0 {
+				line += fmt.Sprintf(" → <code>%d</code>", group.MigratedTo)
+			}
+			if n := open[group.GroupID]; n > 0 {
+				line += fmt.Sprintf(" — ⏳ %d", n)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	})
+}
+
+// /whois <user_id|@username> yoki foydalanuvchi xabariga reply
+func handleWhoisCommand(message *tgbotapi.Message) {
+	arg := strings.TrimSpace(message.CommandArguments())
+	var userID int64
+	username := ""
+	switch {
+	case arg != "":
+		if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			userID = id
+		} else {
+			username = strings.TrimPrefix(arg, "@")
+		}
+	case message.ReplyToMessage != nil && message.ReplyToMessage.From != nil:
+		userID = message.ReplyToMessage.From.ID
+		username = message.ReplyToMessage.From.UserName
+	default:
+		replyText(message, "❗ Foydalanish: /whois <user_id|@username> (yoki xabariga reply)")
+		return
+	}
+
+	// Username bo'yicha qidirilsa ID xabarlar tarixidan topiladi
+	all, err := state.QueryPending(PendingQuery{UserID: userID})
+	if err != nil {
+		log.Printf("❌ Mijoz tarixini o'qishda xato: %v", err)
+		replyText(message, "❌ Xabarlarni o'qib bo'lmadi")
+		return
+	}
+	var history []*PendingMessage
+	for _, msg := range all {
+		if userID != 0 || strings.EqualFold(msg.Username, username) {
+			history = append(history, msg)
+		}
+	}
+	if userID == 0 && len(history) > 0 {
+		userID = history[0].UserID
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔎 <b>%s</b>", html.EscapeString(arg))
+	if userID != 0 {
+		fmt.Fprintf(&sb, " (ID: <code>%d</code>)", userID)
+	}
+	sb.WriteString("\n\n")
+
+	found := false
+	if member, ok := staff.Get(userID); ok {
+		found = true
+		fmt.Fprintf(&sb, "👥 Xodim: %s - %s\n", html.EscapeString(member.Name), member.Role)
+		if len(member.Countries) > 0 {
+			fmt.Fprintf(&sb, "📍 Mas'ul: %s\n", html.EscapeString(strings.Join(member.Countries, ", ")))
+		}
+	}
+
+	var adminOf []string
+	for _, group := range state.ListGroups() {
+		for _, id := range group.AdminIDs {
+			if id == userID && userID != 0 {
+				adminOf = append(adminOf, html.EscapeString(group.GroupTitle))
+			}
+		}
+	}
+	if len(adminOf) > 0 {
+		found = true
+		fmt.Fprintf(&sb, "🛡️ Guruh admini: %s\n", strings.Join(adminOf, ", "))
+	}
+
+	if len(history) > 0 {
+		found = true
+		groups := make(map[string]int)
+		open := 0
+		for _, msg := range history {
+			groups[msg.GroupTitle]++
+			if msg.isOpen() {
+				open++
+			}
+		}
+		last := history[len(history)-1]
+		fmt.Fprintf(&sb, "📨 Mijoz xabarlari: %d ta (javobsiz: %d)\n", len(history), open)
+		fmt.Fprintf(&sb, "🕐 Oxirgi xabar: %s\n", last.Timestamp.Format("02.01.2006 15:04"))
+		sb.WriteString("🏢 Guruhlar:\n")
+		for _, line := range topCounts(groups, 10) {
+			sb.WriteString(line)
+		}
+	}
+
+	if !found {
+		sb.WriteString("❓ Ma'lumot topilmadi")
+	}
+	replyHTML(message, sb.String())
+}
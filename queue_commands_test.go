@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseMessageLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want PendingKey
+		ok   bool
+	}{
+		{link: "https://t.me/c/1234567890/45", want: PendingKey{GroupID: -1001234567890, MessageID: 45}, ok: true},
+		// Forum guruhi: topic ID si xabar ID sidan oldin keladi
+		{link: "https://t.me/c/1234567890/12/45", want: PendingKey{GroupID: -1001234567890, MessageID: 45}, ok: true},
+		{link: "t.me/c/1234567890/45?thread=12", want: PendingKey{GroupID: -1001234567890, MessageID: 45}, ok: true},
+		{link: "https://t.me/somegroup/45"},
+		{link: "https://t.me/c/1234567890"},
+		{link: "salom"},
+	}
+	for _, tt := range tests {
+		got, ok := parseMessageLink(tt.link)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseMessageLink(%q) = %v, %v; kutilgan %v, %v", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// Bot yaratgan havola qayta o'qilishi kerak
+	msg := &PendingMessage{GroupID: -1009876543210, MessageID: 7}
+	if got, ok := parseMessageLink(messageLink(msg)); !ok || got != msg.Key() {
+		t.Errorf("parseMessageLink(%q) = %v, %v", messageLink(msg), got, ok)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Eslatma kartalari yuboriladigan xodimlar chati (admin guruhi yoki rahbarlar chati)
+func isStaffChat(chatID int64) bool {
+	return chatID == cfg.AdminChatID || (cfg.ManagersChatID != 0 && chatID == cfg.ManagersChatID)
+}
+
+// Kartaga reply orqali javob berish huquqi: registrdagi xodim roli bo'yicha,
+// registrda bo'lmaganlar esa xodimlar chati a'zosi sifatida
+func canRelayAnswer(message *tgbotapi.Message) bool {
+	if member, registered := staff.Get(message.From.ID); registered {
+		return member.Role.canAnswer()
+	}
+	return isStaffChat(message.Chat.ID)
+}
+
+// Xodim admin topicidagi eslatma kartasiga reply qilsa, javob (matn, rasm,
+// hujjat, ovozli xabar) mijoz guruhiga asl xabarga reply sifatida ko'chiriladi,
+// xabar javob berilgan deb belgilanadi va eslatmalar o'chiriladi.
+// Xabar kartaga javob bo'lmasa false
+func relayStaffReply(message *tgbotapi.Message) bool {
+	reply := message.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.ID != bot.Self.ID || message.From == nil {
+		return false
+	}
+
+	chatID := message.Chat.ID
+	matches := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.isOpen() && msg.hasReminder(chatID, reply.MessageID)
+	})
+	if len(matches) == 0 {
+		return false
+	}
+	pendingMsg := matches[0]
+
+	if !canRelayAnswer(message) {
+		replyText(message, "⛔ Kuzatuvchi (viewer) mijozga javob yubora olmaydi")
+		return true
+	}
+
+	copiedID, err := copyMessage(CopyMessageConfig{
+		ChatID:           pendingMsg.GroupID,
+		MessageThreadID:  pendingMsg.ThreadID,
+		FromChatID:       chatID,
+		MessageID:        message.MessageID,
+		ReplyToMessageID: pendingMsg.MessageID,
+	})
+	if err != nil {
+		log.Printf("❌ Javobni guruhga yuborib bo'lmadi (%s): %v", pendingMsg.Key(), err)
+		replyText(message, fmt.Sprintf("❌ Javobni %s guruhiga yuborib bo'lmadi: %v", pendingMsg.GroupTitle, err))
+		return true
+	}
+
+	name := displayName(message.From)
+	answered, ok := state.MarkAnswered(pendingMsg.Key(), message.From.ID, name)
+	if !ok {
+		return true
+	}
+	log.Printf("📤 %s javobi admin topicidan guruhga yuborildi: %s -> MSG %d", name, answered.Key(), copiedID)
+
+	// Karta vazifasini bajardi - javob endi mijoz guruhida
+	deleteSentMessages(answered)
+	return true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Xodim kartaning o'ziga yoki uning ostidagi fayl nusxasiga reply qilishi mumkin
+func TestRelayStaffReplyMatchesCardAttachments(t *testing.T) {
+	const (
+		adminChatID = -100
+		cardID      = 40
+		photoID     = 41
+		documentID  = 42
+	)
+	key := PendingKey{GroupID: -500, MessageID: 7}
+
+	tests := []struct {
+		name        string
+		replyTo     int
+		wantRelayed bool
+	}{
+		{name: "kartaning o'zi", replyTo: cardID, wantRelayed: true},
+		{name: "rasm nusxasi", replyTo: photoID, wantRelayed: true},
+		{name: "hujjat nusxasi", replyTo: documentID, wantRelayed: true},
+		{name: "boshqa bot xabari", replyTo: 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousCfg, previousState := cfg, state
+			t.Cleanup(func() { cfg, state = previousCfg, previousState })
+			cfg = &Config{AdminChatID: adminChatID}
+			state = newTestState(t)
+			calls := newTestBot(t)
+			withTestStaff(t)
+			state.AddPending(&PendingMessage{
+				GroupID:   key.GroupID,
+				MessageID: key.MessageID,
+				Status:    "pending",
+				Timestamp: time.Now(),
+				SentReminders: []SentReminder{
+					{ChatID: adminChatID, MessageID: cardID, Attachments: []int{photoID, documentID}},
+				},
+			})
+
+			chat := &tgbotapi.Chat{ID: adminChatID, Type: "supergroup"}
+			handled := relayStaffReply(&tgbotapi.Message{
+				MessageID:      50,
+				From:           &tgbotapi.User{ID: 77, FirstName: "Admin"},
+				Chat:           chat,
+				Text:           "Javob",
+				ReplyToMessage: &tgbotapi.Message{MessageID: tt.replyTo, From: &bot.Self, Chat: chat},
+			})
+			if handled != tt.wantRelayed {
+				t.Fatalf("relayStaffReply = %v, kutilgan %v", handled, tt.wantRelayed)
+			}
+
+			var copied int
+			for _, call := range calls() {
+				if call.Method == "copyMessage" && call.Params.Get("chat_id") == "-500" {
+					copied++
+				}
+			}
+			want := 0
+			if tt.wantRelayed {
+				want = 1
+			}
+			if copied != want {
+				t.Errorf("guruhga %d ta nusxa yuborildi, kutilgan %d", copied, want)
+			}
+
+			msg, ok := state.GetPending(key)
+			if !ok {
+				t.Fatal("xabar topilmadi")
+			}
+			if msg.isOpen() == tt.wantRelayed {
+				t.Errorf("xabar holati %q", msg.Status)
+			}
+		})
+	}
+}
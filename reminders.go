@@ -0,0 +1,422 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Eslatmani o'chirishga urinishlar soni
+const deleteAttempts = 3
+
+// Telegram 48 soatdan eski xabarlarni o'chirishga ruxsat bermaydi -
+// bunday eslatmalar "hal qilindi" holatiga tahrirlanadi
+const resolvedReminderText = "✅ Hal qilindi"
+
+// Yuborilgan eslatma: qaysi chat/topicga yuborilgani bilan birga
+type SentReminder struct {
+	ChatID    int64     `json:"chat_id"`
+	ThreadID  int       `json:"thread_id,omitempty"`
+	MessageID int       `json:"message_id"`
+	SentAt    time.Time `json:"sent_at"`
+	Mentions  []int64   `json:"mentions,omitempty"` // Kartada belgilangan xodimlar
+	// Karta ostiga ko'chirilgan mijoz fayllari (shu chat/topicda)
+	Attachments []int `json:"attachments,omitempty"`
+	// Topic yopiq bo'lib karta umumiy topicga tushgan bo'lsa - aslida so'ralgan topic
+	RequestedThreadID int `json:"requested_thread_id,omitempty"`
+}
+
+// Karta shu chat/topic uchun yuborilganmi. Umumiy topicga fallback qilingan
+// karta so'ralgan topic bo'yicha ham topiladi - aks holda har pog'onada yangi karta qo'shilardi
+func (r SentReminder) targets(chatID int64, threadID int) bool {
+	if r.ChatID != chatID {
+		return false
+	}
+	return r.ThreadID == threadID || (r.RequestedThreadID != 0 && r.RequestedThreadID == threadID)
+}
+
+// Eski formatdagi SentMessageIDs ni SentReminders ga o'tkazish.
+// Avval barcha eslatmalar admin guruhidan o'chirilgan, shuning uchun chat shu
+func (m *PendingMessage) migrateSentMessageIDs(adminChatID int64) bool {
+	if len(m.SentMessageIDs) == 0 {
+		return false
+	}
+	for _, messageID := range m.SentMessageIDs {
+		m.SentReminders = append(m.SentReminders, SentReminder{ChatID: adminChatID, MessageID: messageID, SentAt: m.LastReminder})
+	}
+	m.SentMessageIDs = nil
+	return true
+}
+
+// Xabar shu chatdagi eslatma kartalaridan biri yoki karta ostiga
+// ko'chirilgan fayl nusxasi ekanligini tekshirish
+func (m *PendingMessage) hasReminder(chatID int64, messageID int) bool {
+	for _, r := range m.SentReminders {
+		if r.ChatID != chatID {
+			continue
+		}
+		if r.MessageID == messageID {
+			return true
+		}
+		for _, attachmentID := range r.Attachments {
+			if attachmentID == messageID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Telegram xatosini tekshirish (xato matni bo'yicha)
+func apiErrorContains(err error, marker string) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.Message), marker)
+}
+
+// Bitta eslatmani o'chirish. Vaqtinchalik xatolarda qayta urinadi,
+// o'chirib bo'lmaydigan (48 soatdan eski) xabarni tahrirlaydi
+func deleteReminder(r SentReminder) error {
+	var err error
+	for attempt := 1; attempt <= deleteAttempts; attempt++ {
+		_, err = bot.Request(tgbotapi.NewDeleteMessage(r.ChatID, r.MessageID))
+		if err == nil || apiErrorContains(err, "message to delete not found") {
+			deleteAttachments(r)
+			return nil
+		}
+
+		if apiErrorContains(err, "message can't be deleted") {
+			return resolveReminder(r)
+		}
+
+		// Flood limit - Telegram aytgan vaqtcha kutish
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+			continue
+		}
+		// Boshqa API xatolari (huquq yo'q va h.k.) qayta urinish bilan tuzalmaydi
+		if errors.As(err, &apiErr) {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	return err
+}
+
+// O'chirib bo'lmaydigan eslatmani "hal qilindi" holatiga tahrirlash
+func resolveReminder(r SentReminder) error {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(r.ChatID, r.MessageID, resolvedReminderText,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := bot.Request(edit); err != nil && !apiErrorContains(err, "message is not modified") {
+		return fmt.Errorf("o'chirib ham, tahrirlab ham bo'lmadi: %w", err)
+	}
+	log.Printf("✏️ Eskirgan eslatma tahrirlandi (Chat: %d, MSG ID: %d)", r.ChatID, r.MessageID)
+	return nil
+}
+
+// Barcha yuborilgan eslatma xabarlarini o'zi yuborilgan chatdan o'chirish
+func deleteSentMessages(pendingMsg *PendingMessage) {
+	if len(pendingMsg.SentReminders) == 0 {
+		return
+	}
+
+	deletedCount := 0
+	var failed []string
+	for _, r := range pendingMsg.SentReminders {
+		if err := deleteReminder(r); err != nil {
+			log.Printf("❌ Eslatma xabarini o'chirishda xato (Chat: %d, Thread: %d, MSG ID: %d): %v", r.ChatID, r.ThreadID, r.MessageID, err)
+			failed = append(failed, fmt.Sprintf("%d/%d", r.ChatID, r.MessageID))
+			continue
+		}
+		deletedCount++
+		log.Printf("🗑️ Eslatma xabari o'chirildi (Chat: %d, MSG ID: %d)", r.ChatID, r.MessageID)
+	}
+
+	log.Printf("🗑️ Jami %d ta eslatma xabari o'chirildi", deletedCount)
+	if len(failed) > 0 {
+		log.Printf("⚠️ MSG %s: %d ta eslatma o'chirilmadi: %s", pendingMsg.Key(), len(failed), strings.Join(failed, ", "))
+	}
+
+	// SentReminders ni tozalash
+	pendingMsg.SentReminders = nil
+}
+
+// Xodimni HTML matnda belgilash (username bo'lmasa ham ishlaydi)
+func mentionHTML(userID int64) string {
+	name := strconv.FormatInt(userID, 10)
+	if member, ok := staff.Get(userID); ok {
+		name = member.Name
+	}
+	return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, userID, html.EscapeString(name))
+}
+
+// Jonli eslatma kartasining matni (HTML)
+func reminderCardText(msg *PendingMessage, mentions []int64) string {
+	header := fmt.Sprintf("⚠️ JAVOBSIZ XABAR! (%d-ESLATMA, %d-pog'ona)", msg.ReminderCount, msg.EscalationLevel)
+	footer := "Iltimos tezroq javob bering!"
+	if msg.Status == "overdue" {
+		header = fmt.Sprintf("⛔ MUDDATI O'TDI! (%d ta eslatma)", msg.ReminderCount)
+		footer = "Eslatmalar to'xtatildi - javob berilmagan!"
+	}
+
+	if msg.snoozed(time.Now()) {
+		footer = fmt.Sprintf("💤 %s gacha kechiktirildi (%s)", msg.SnoozedUntil.Format("02.01.2006 15:04"), html.EscapeString(msg.SnoozedBy))
+	}
+
+	mentionLine := ""
+	if len(mentions) > 0 {
+		names := make([]string, len(mentions))
+		for i, userID := range mentions {
+			names[i] = mentionHTML(userID)
+		}
+		mentionLine = "\n👥 Mas'ul: " + strings.Join(names, ", ")
+	}
+	if msg.AssigneeID != 0 {
+		mentionLine += "\n🙋 Oldi: " + html.EscapeString(msg.AssigneeName)
+		if n := len(msg.Assignments); n > 1 {
+			mentionLine += fmt.Sprintf(" (%d marta tayinlangan)", n)
+		}
+	}
+
+	return fmt.Sprintf(`%s
+
+🏢 Guruh: %s
+📍 Davlat: %s
+👤 Foydalanuvchi: @%s (ID: %d)
+⏰ Xabar vaqti: %s
+%s
+
+🔔 %s dan beri javob kutmoqda!
+⏱️ Jami eslatmalar: %d%s
+
+%s`,
+		header,
+		html.EscapeString(msg.GroupTitle),
+		html.EscapeString(msg.Country),
+		html.EscapeString(msg.Username),
+		msg.UserID,
+		msg.Timestamp.Format("02.01.2006 15:04:05"),
+		messageContentHTML(msg),
+		formatDuration(time.Since(msg.Timestamp)),
+		msg.ReminderCount,
+		mentionLine,
+		footer)
+}
+
+// Yopilgan xabar kartasi: kim va qancha vaqtdan keyin javob bergani
+// (yoki javob shart emas deb belgilagani)
+func resolvedCardText(msg *PendingMessage) string {
+	answeredBy := html.EscapeString(msg.AnsweredByName)
+	if answeredBy == "" {
+		answeredBy = fmt.Sprintf("ID %d", msg.AnsweredBy)
+	}
+	if msg.Status == "deleted" {
+		return fmt.Sprintf(`🗑️ XABAR O'CHIRILGAN
+
+🏢 Guruh: %s
+👤 Foydalanuvchi: @%s
+%s
+
+🙋 Yopdi: %s`,
+			html.EscapeString(msg.GroupTitle),
+			html.EscapeString(msg.Username),
+			messageContentHTML(msg),
+			answeredBy)
+	}
+	if msg.Status == "ignored" {
+		text := fmt.Sprintf(`🙈 JAVOB SHART EMAS
+
+🏢 Guruh: %s
+👤 Foydalanuvchi: @%s
+%s
+
+🙋 Belgiladi: %s`,
+			html.EscapeString(msg.GroupTitle),
+			html.EscapeString(msg.Username),
+			messageContentHTML(msg),
+			answeredBy)
+		if msg.CloseReason != "" {
+			text += "\n📝 Sabab: " + html.EscapeString(msg.CloseReason)
+		}
+		return text
+	}
+	return fmt.Sprintf(`✅ JAVOB BERILDI
+
+🏢 Guruh: %s
+👤 Foydalanuvchi: @%s
+%s
+
+🙋 Javob berdi: %s
+⏱️ %s dan keyin (%d ta eslatma)`,
+		html.EscapeString(msg.GroupTitle),
+		html.EscapeString(msg.Username),
+		messageContentHTML(msg),
+		answeredBy,
+		formatDuration(msg.AnsweredAt.Sub(msg.Timestamp)),
+		msg.ReminderCount)
+}
+
+// Xabarni ochish havolasi
+func messageLink(msg *PendingMessage) string {
+	return fmt.Sprintf("https://t.me/c/%d/%d", -msg.GroupID-1000000000000, msg.MessageID)
+}
+
+// Jonli karta tugmalari: "Javob berildi" va "Xabarni ko'rish"
+func reminderCardKeyboard(msg *PendingMessage) tgbotapi.InlineKeyboardMarkup {
+	var snoozeRow []tgbotapi.InlineKeyboardButton
+	for _, option := range snoozeOptions {
+		snoozeRow = append(snoozeRow, tgbotapi.NewInlineKeyboardButtonData(option.label, fmt.Sprintf("snooze_%s_%d_%d", option.name, msg.GroupID, msg.MessageID)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Javob berildi", fmt.Sprintf("mark_answered_%d_%d", msg.GroupID, msg.MessageID)),
+			tgbotapi.NewInlineKeyboardButtonData("🙈 Javob shart emas", fmt.Sprintf("ignore_%d_%d", msg.GroupID, msg.MessageID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🙋 Men olaman", fmt.Sprintf("claim_%d_%d", msg.GroupID, msg.MessageID)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 O'chirilgan", fmt.Sprintf("deleted_%d_%d", msg.GroupID, msg.MessageID)),
+		),
+		snoozeRow,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("📄 Xabarni ko'rish", messageLink(msg)),
+		),
+	)
+}
+
+// Kartani joyida tahrirlash. Matn o'zgarmagan bo'lsa xato emas
+func editCard(r SentReminder, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(r.ChatID, r.MessageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.DisableWebPagePreview = true
+	if _, err := bot.Request(edit); err != nil && !apiErrorContains(err, "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// Yangi auditoriya: boshqa chat/topic yoki avval belgilanmagan xodimlar
+func (r SentReminder) coversAudience(chatID int64, threadID int, mentions []int64) bool {
+	if !r.targets(chatID, threadID) {
+		return false
+	}
+	known := make(map[int64]bool, len(r.Mentions))
+	for _, id := range r.Mentions {
+		known[id] = true
+	}
+	for _, id := range mentions {
+		if !known[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Eslatma kartasini yetkazish. Shu auditoriyaga karta bor bo'lsa u joyida
+// tahrirlanadi, aks holda yangi karta yuboriladi (shu topicdagi eskisi o'chiriladi -
+// belgilangan xodimlarga bildirishnoma faqat yangi xabarda boradi).
+// Xabarning yangilangan kartalar ro'yxati qaytariladi
+func deliverReminderCard(msg *PendingMessage, chatID int64, threadID int, mentions []int64) ([]SentReminder, bool) {
+	cards := append([]SentReminder(nil), msg.SentReminders...)
+	text := reminderCardText(msg, mentions)
+	keyboard := reminderCardKeyboard(msg)
+
+	existing := -1
+	for i, card := range cards {
+		if card.targets(chatID, threadID) {
+			existing = i
+		}
+	}
+
+	if existing >= 0 && cards[existing].coversAudience(chatID, threadID, mentions) {
+		err := editCard(cards[existing], reminderCardText(msg, cards[existing].Mentions), keyboard)
+		if err == nil {
+			log.Printf("✏️ Eslatma kartasi yangilandi: MSG %s (Chat: %d, MSG ID: %d)", msg.Key(), chatID, cards[existing].MessageID)
+			return cards, true
+		}
+		// Faqat karta qo'lda o'chirilgan bo'lsa yangisi yuboriladi. Flood limit yoki
+		// tarmoq xatosida qayta yuborish guruhni yanada ko'proq xabar bilan to'ldiradi -
+		// pog'ona keyingi tekshiruvda qayta uriniladi
+		if !apiErrorContains(err, "message to edit not found") {
+			log.Printf("❌ Eslatma kartasini tahrirlab bo'lmadi (Chat: %d, MSG ID: %d): %v", chatID, cards[existing].MessageID, err)
+			return msg.SentReminders, false
+		}
+		log.Printf("⚠️ Eslatma kartasi o'chirilgan (MSG ID: %d), yangisi yuboriladi", cards[existing].MessageID)
+	}
+
+	sentMsg, sentThreadID, err := sendToTopic(ThreadMessageConfig{
+		ChatID:                chatID,
+		MessageThreadID:       threadID,
+		Text:                  text,
+		ParseMode:             tgbotapi.ModeHTML,
+		ReplyMarkup:           keyboard,
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		log.Printf("❌ Topicga eslatma yuborishda xato: %v", err)
+		return msg.SentReminders, false
+	}
+	log.Printf("✅ Topic %d ga eslatma yuborildi (MSG ID: %d)", sentThreadID, sentMsg.MessageID)
+
+	card := SentReminder{ChatID: chatID, ThreadID: sentThreadID, MessageID: sentMsg.MessageID, SentAt: time.Now(), Mentions: mentions}
+	if sentThreadID != threadID {
+		card.RequestedThreadID = threadID
+	}
+	card.Attachments = copyMediaToCard(msg, card, msg.Media)
+	if existing >= 0 {
+		if err := deleteReminder(cards[existing]); err != nil {
+			log.Printf("⚠️ Eski eslatma kartasini o'chirib bo'lmadi: %v", err)
+		}
+		cards[existing] = card
+	} else {
+		cards = append(cards, card)
+	}
+	return cards, true
+}
+
+// Barcha kartalarni joriy holat bilan qayta chizish (kutish vaqti yangilanadi)
+func refreshReminderCards(msg *PendingMessage) {
+	keyboard := reminderCardKeyboard(msg)
+	for _, card := range msg.SentReminders {
+		if err := editCard(card, reminderCardText(msg, card.Mentions), keyboard); err != nil {
+			log.Printf("⚠️ Eslatma kartasini yangilab bo'lmadi (Chat: %d, MSG ID: %d): %v", card.ChatID, card.MessageID, err)
+		}
+	}
+}
+
+// Javob berilgan xabar kartalarini "✅ javob berildi" xulosasiga aylantirish.
+// Tahrirlab bo'lmagan karta o'chiriladi
+func resolveSentMessages(pendingMsg *PendingMessage) {
+	if len(pendingMsg.SentReminders) == 0 {
+		return
+	}
+
+	text := resolvedCardText(pendingMsg)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("📄 Xabarni ko'rish", messageLink(pendingMsg)),
+		),
+	)
+
+	var failed []SentReminder
+	for _, card := range pendingMsg.SentReminders {
+		err := editCard(card, text, keyboard)
+		if err == nil || apiErrorContains(err, "message to edit not found") {
+			continue
+		}
+		log.Printf("⚠️ Kartani xulosaga aylantirib bo'lmadi (Chat: %d, MSG ID: %d): %v", card.ChatID, card.MessageID, err)
+		failed = append(failed, card)
+	}
+	log.Printf("✅ MSG %s: %d ta eslatma kartasi yopildi", pendingMsg.Key(), len(pendingMsg.SentReminders)-len(failed))
+
+	if len(failed) > 0 {
+		deleteSentMessages(&PendingMessage{GroupID: pendingMsg.GroupID, MessageID: pendingMsg.MessageID, SentReminders: failed})
+	}
+	pendingMsg.SentReminders = nil
+}
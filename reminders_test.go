@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestSentReminderTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		card     SentReminder
+		chatID   int64
+		threadID int
+		want     bool
+	}{
+		{"shu topic", SentReminder{ChatID: -100, ThreadID: 4}, -100, 4, true},
+		{"boshqa topic", SentReminder{ChatID: -100, ThreadID: 4}, -100, 6, false},
+		{"boshqa chat", SentReminder{ChatID: -200, ThreadID: 4}, -100, 4, false},
+		{"fallback karta so'ralgan topic bo'yicha", SentReminder{ChatID: -100, ThreadID: 0, RequestedThreadID: 4}, -100, 4, true},
+		{"fallback karta umumiy topic bo'yicha", SentReminder{ChatID: -100, ThreadID: 0, RequestedThreadID: 4}, -100, 0, true},
+		{"eski karta so'ralgan topicsiz", SentReminder{ChatID: -100, ThreadID: 4}, -100, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.targets(tt.chatID, tt.threadID); got != tt.want {
+				t.Errorf("targets(%d, %d) = %v, kutilgan %v", tt.chatID, tt.threadID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentReminderCoversAudience(t *testing.T) {
+	card := SentReminder{ChatID: -100, ThreadID: 0, RequestedThreadID: 4, Mentions: []int64{1, 2}}
+	if !card.coversAudience(-100, 4, []int64{2}) {
+		t.Error("fallback karta shu auditoriyani qamrashi kerak")
+	}
+	if card.coversAudience(-100, 4, []int64{3}) {
+		t.Error("yangi xodim belgilansa yangi karta kerak")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram xizmat akkauntlari: sender_chat bilan kelgan xabarlarda From shular bo'ladi
+const (
+	ANONYMOUS_ADMIN_BOT_ID = 1087968824 // @GroupAnonymousBot - anonim admin xabarlari
+	CHANNEL_BOT_ID         = 136817688  // @Channel_Bot - kanal nomidan yozilgan xabarlar
+	TELEGRAM_SERVICE_ID    = 777000     // Bog'langan kanaldan avtomatik forward
+)
+
+// Xabar kim nomidan yuborilgani
+type SenderKind string
+
+const (
+	SENDER_USER            SenderKind = "user"            // Oddiy foydalanuvchi (mijoz yoki xodim)
+	SENDER_ANONYMOUS_ADMIN SenderKind = "anonymous_admin" // Guruh nomidan yozgan anonim admin
+	SENDER_LINKED_CHANNEL  SenderKind = "linked_channel"  // Guruhga bog'langan kompaniya kanali
+	SENDER_CHANNEL         SenderKind = "channel"         // Boshqa kanal nomidan yozgan foydalanuvchi
+	SENDER_AUTO_FORWARD    SenderKind = "auto_forward"    // Kanal postining avtomatik nusxasi
+)
+
+// Xabar yuboruvchisi: user yoki sender_chat dan aniqlanadi
+type Sender struct {
+	Kind     SenderKind
+	ID       int64 // User ID yoki sender_chat ID si (kanal/guruh uchun manfiy)
+	Name     string
+	Username string
+	Role     StaffRole
+	IsStaff  bool
+}
+
+// Xabar e'tiborga olinmaydi (na savol, na javob)
+func (s Sender) Ignored() bool {
+	return s.Kind == SENDER_AUTO_FORWARD
+}
+
+// Xabar yuboruvchisini aniqlash. Anonim admin va bog'langan kanal xodim
+// hisoblanadi, avtomatik forward e'tiborga olinmaydi, boshqa kanal nomidan
+// yozgan foydalanuvchi mijoz sifatida kuzatiladi
+func classifySender(message *tgbotapi.Message) Sender {
+	groupID := message.Chat.ID
+
+	if message.IsAutomaticForward || (message.From != nil && message.From.ID == TELEGRAM_SERVICE_ID) {
+		return Sender{Kind: SENDER_AUTO_FORWARD, ID: TELEGRAM_SERVICE_ID, Name: "Kanal posti"}
+	}
+
+	if senderChat := message.SenderChat; senderChat != nil {
+		if senderChat.ID == groupID {
+			return anonymousAdmin(groupID, message.AuthorSignature)
+		}
+
+		name := senderChat.Title
+		if name == "" {
+			name = senderChat.UserName
+		}
+		if group, ok := state.GetGroup(groupID); ok && group.LinkedChatID != 0 && group.LinkedChatID == senderChat.ID {
+			return Sender{Kind: SENDER_LINKED_CHANNEL, ID: senderChat.ID, Name: name, Username: senderChat.UserName, Role: groupAdminRole, IsStaff: true}
+		}
+		return Sender{Kind: SENDER_CHANNEL, ID: senderChat.ID, Name: name, Username: senderChat.UserName}
+	}
+
+	// sender_chat siz kelgan eski anonim admin xabarlari
+	if message.From != nil && message.From.ID == ANONYMOUS_ADMIN_BOT_ID {
+		return anonymousAdmin(groupID, message.AuthorSignature)
+	}
+
+	user := message.From
+	if user == nil {
+		return Sender{Kind: SENDER_USER}
+	}
+	sender := Sender{Kind: SENDER_USER, ID: user.ID, Name: user.FirstName, Username: user.UserName}
+	sender.Role, sender.IsStaff = senderRole(user, groupID)
+	return sender
+}
+
+// Anonim admin: faqat guruh adminlari guruh nomidan yoza oladi.
+// Javob beruvchi sifatida guruh ID si yoziladi
+func anonymousAdmin(groupID int64, signature string) Sender {
+	name := "Anonim admin"
+	if signature = strings.TrimSpace(signature); signature != "" {
+		name += " (" + signature + ")"
+	}
+	return Sender{Kind: SENDER_ANONYMOUS_ADMIN, ID: groupID, Name: name, Role: groupAdminRole, IsStaff: true}
+}
+
+// Xizmat akkaunti xabari yopilganda kartada ko'rinadigan sabab
+const serviceAccountCloseReason = "Anonim admin yoki bog'langan kanal xabari - mijoz savoli emas"
+
+// Xato mijoz savoli sifatida saqlangan anonim admin yoki bog'langan kanal xabari.
+// CHANNEL_BOT_ID bu yerga kirmaydi: boshqa kanal nomidan yozgan foydalanuvchi
+// classifySender da mijoz hisoblanadi, eski yozuvlarda esa kanalni ajratib bo'lmaydi
+func isServiceAccountMessage(msg *PendingMessage) bool {
+	switch msg.UserID {
+	case ANONYMOUS_ADMIN_BOT_ID, TELEGRAM_SERVICE_ID:
+		return true
+	}
+	return false
+}
+
+// Ishga tushishda xizmat akkauntlarining ochiq xabarlarini "ignored" holatida
+// yopish. Yozuvlar o'chirilmaydi - tarix va statistika saqlanib qoladi
+func closeServiceAccountTickets() {
+	open := state.FindPending(func(msg *PendingMessage) bool {
+		return msg.isOpen() && isServiceAccountMessage(msg)
+	})
+	for _, msg := range open {
+		closed, ok := state.CloseTicketWithReason(msg.Key(), "ignored", 0, "Bot", serviceAccountCloseReason)
+		if !ok {
+			continue
+		}
+		log.Printf("🧹 Xizmat akkaunti xabari yopildi: %s (%s)", msg.Key(), msg.Username)
+		resolveSentMessages(closed)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloseServiceAccountTickets(t *testing.T) {
+	previous := state
+	t.Cleanup(func() { state = previous })
+	state = newTestState(t)
+	newTestBot(t)
+
+	now := time.Now()
+	tickets := []struct {
+		msg        *PendingMessage
+		wantStatus string
+	}{
+		{&PendingMessage{GroupID: -1, MessageID: 1, UserID: ANONYMOUS_ADMIN_BOT_ID, Status: "pending"}, "ignored"},
+		{&PendingMessage{GroupID: -1, MessageID: 2, UserID: TELEGRAM_SERVICE_ID, Status: "overdue"}, "ignored"},
+		// Boshqa kanal nomidan yozgan foydalanuvchi - mijoz sifatida qoladi
+		{&PendingMessage{GroupID: -1, MessageID: 3, UserID: CHANNEL_BOT_ID, Status: "pending"}, "pending"},
+		{&PendingMessage{GroupID: -1, MessageID: 4, UserID: 42, Status: "pending"}, "pending"},
+	}
+	for _, tt := range tickets {
+		tt.msg.Timestamp = now
+		state.AddPending(tt.msg)
+	}
+	// Javob berilgan tarix o'zgarmaydi va o'chirilmaydi
+	state.AddPending(&PendingMessage{GroupID: -1, MessageID: 5, UserID: ANONYMOUS_ADMIN_BOT_ID, Status: "pending", Timestamp: now})
+	state.MarkAnswered(PendingKey{GroupID: -1, MessageID: 5}, 7, "Admin")
+
+	closeServiceAccountTickets()
+
+	for _, tt := range tickets {
+		msg, ok := state.GetPending(tt.msg.Key())
+		if !ok {
+			t.Fatalf("%s o'chirib yuborilgan", tt.msg.Key())
+		}
+		if msg.Status != tt.wantStatus {
+			t.Errorf("%s holati %q, kutilgan %q", msg.Key(), msg.Status, tt.wantStatus)
+		}
+		if msg.Status == "ignored" && msg.CloseReason != serviceAccountCloseReason {
+			t.Errorf("%s yopilish sababi %q", msg.Key(), msg.CloseReason)
+		}
+	}
+	if msg, ok := state.GetPending(PendingKey{GroupID: -1, MessageID: 5}); !ok || msg.Status != "answered" || msg.AnsweredByName != "Admin" {
+		t.Errorf("javob berilgan xabar o'zgargan: %+v, %v", msg, ok)
+	}
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Xodim roli
+type StaffRole string
+
+const (
+	ROLE_MANAGER    StaffRole = "manager"    // Javob beradi va xodimlarni boshqaradi
+	ROLE_CONSULTANT StaffRole = "consultant" // Mijozlarga javob beradi
+	ROLE_VIEWER     StaffRole = "viewer"     // Faqat kuzatadi - xabarlari kuzatilmaydi, javob hisoblanmaydi
+)
+
+// Guruhning Telegram adminlari uchun rol (registrda bo'lmasa)
+const groupAdminRole = ROLE_CONSULTANT
+
+func parseStaffRole(s string) (StaffRole, bool) {
+	switch role := StaffRole(strings.ToLower(s)); role {
+	case ROLE_MANAGER, ROLE_CONSULTANT, ROLE_VIEWER:
+		return role, true
+	}
+	return "", false
+}
+
+// Xodim xabari mijoz savoliga javob hisoblanadimi
+func (r StaffRole) canAnswer() bool {
+	return r == ROLE_MANAGER || r == ROLE_CONSULTANT
+}
+
+// Ro'yxatdagi xodim
+type StaffMember struct {
+	UserID   int64     `json:"user_id"`
+	Name     string    `json:"name"`
+	Username string    `json:"username,omitempty"`
+	Role     StaffRole `json:"role"`
+	// Mas'ul davlatlar - eskalatsiyada belgilash uchun. Bo'sh bo'lsa barcha davlatlar
+	Countries []string  `json:"countries,omitempty"`
+	AddedBy   int64     `json:"added_by,omitempty"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type StaffData struct {
+	Staff []StaffMember `json:"staff"`
+}
+
+// Xodimlar registri: user ID -> rol. staff.json faylida saqlanadi,
+// /staff buyruqlari orqali o'zgaradi
+type StaffRegistry struct {
+	mu    sync.RWMutex
+	path  string
+	staff map[int64]StaffMember
+}
+
+// Global xodimlar registri
+var staff *StaffRegistry
+
+// Registrni fayldan yuklash. Fayl hali yo'q bo'lsa registr bo'sh boshlanadi
+func loadStaff(path string) (*StaffRegistry, error) {
+	r := &StaffRegistry{path: path, staff: make(map[int64]StaffMember)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Printf("👤 Xodimlar fayli topilmadi (%s), registr bo'sh", path)
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xodimlar faylini o'qib bo'lmadi: %w", err)
+	}
+
+	var staffData StaffData
+	if err := json.Unmarshal(data, &staffData); err != nil {
+		return nil, fmt.Errorf("xodimlar fayli noto'g'ri (%s): %w", path, err)
+	}
+	for _, member := range staffData.Staff {
+		if _, ok := parseStaffRole(string(member.Role)); !ok || member.UserID == 0 {
+			return nil, fmt.Errorf("xodimlar faylida noto'g'ri yozuv: %+v", member)
+		}
+		r.staff[member.UserID] = member
+	}
+
+	log.Printf("✅ %d ta xodim yuklandi", len(r.staff))
+	return r, nil
+}
+
+// Xodim shu davlatga mas'ulmi
+func (m StaffMember) responsibleFor(country string) bool {
+	if len(m.Countries) == 0 {
+		return true
+	}
+	for _, c := range m.Countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registrni faylga yozish (lock ostida chaqiriladi)
+func (r *StaffRegistry) saveLocked() error {
+	data, err := json.MarshalIndent(StaffData{Staff: r.listLocked()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data, 0644)
+}
+
+func (r *StaffRegistry) listLocked() []StaffMember {
+	list := make([]StaffMember, 0, len(r.staff))
+	for _, member := range r.staff {
+		list = append(list, member)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Role != list[j].Role {
+			return list[i].Role < list[j].Role
+		}
+		return list[i].UserID < list[j].UserID
+	})
+	return list
+}
+
+// Barcha xodimlar (rol, keyin ID bo'yicha tartiblangan)
+func (r *StaffRegistry) List() []StaffMember {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listLocked()
+}
+
+// User ID bo'yicha xodimni topish
+func (r *StaffRegistry) Get(userID int64) (StaffMember, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.staff[userID]
+	return member, ok
+}
+
+// Username bo'yicha xodimni topish (katta-kichik harf farqsiz)
+func (r *StaffRegistry) FindByUsername(username string) (StaffMember, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.staff {
+		if member.Username != "" && strings.EqualFold(member.Username, username) {
+			return member, true
+		}
+	}
+	return StaffMember{}, false
+}
+
+// Registrda menejer bormi
+func (r *StaffRegistry) HasManagers() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.staff {
+		if member.Role == ROLE_MANAGER {
+			return true
+		}
+	}
+	return false
+}
+
+// Xodim qo'shish yoki rolini o'zgartirish
+func (r *StaffRegistry) Put(member StaffMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.staff[member.UserID] = member
+	return r.saveLocked()
+}
+
+// Xodimni o'chirish
+func (r *StaffRegistry) Remove(userID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.staff[userID]; !ok {
+		return false, nil
+	}
+	delete(r.staff, userID)
+	return true, r.saveLocked()
+}
+
+// Xabar yuboruvchining roli. Tartib: xodimlar registri, guruhning Telegram
+// adminlari, so'ng (sozlamada yoqilgan bo'lsa) username bo'yicha taxmin.
+// Xodim bo'lmasa false - xabar mijoz xabari sifatida kuzatiladi
+func senderRole(user *tgbotapi.User, groupID int64) (StaffRole, bool) {
+	if user == nil {
+		return "", false
+	}
+
+	if member, ok := staff.Get(user.ID); ok {
+		return member.Role, true
+	}
+
+	if group, ok := state.GetGroup(groupID); ok {
+		for _, adminID := range group.AdminIDs {
+			if adminID == user.ID {
+				return groupAdminRole, true
+			}
+		}
+	}
+
+	if isStaffUsername(user.UserName) {
+		return groupAdminRole, true
+	}
+	return "", false
+}
+
+// Eski usul: username da xodimlar belgisi (masalan "globuz") borligini tekshirish
+func isStaffUsername(username string) bool {
+	if !cfg.StaffUsernameFallback || username == "" || cfg.StaffUsernamePattern == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(username), strings.ToLower(cfg.StaffUsernamePattern))
+}
+
+// Xodimlarni boshqarish huquqi: menejerlar. Registrda hali menejer
+// bo'lmasa admin guruhidagi har kim birinchi menejerni qo'sha oladi
+func canManageStaff(user *tgbotapi.User) bool {
+	if member, ok := staff.Get(user.ID); ok && member.Role == ROLE_MANAGER {
+		return true
+	}
+	return !staff.HasManagers()
+}
+
+// Foydalanuvchining ko'rinadigan ismi
+func displayName(user *tgbotapi.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.UserName
+	}
+	return name
+}
+
+// /staff buyrug'i: list, add, remove
+func handleStaffCommand(message *tgbotapi.Message) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		replyText(message, staffUsage)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		list := staff.List()
+		if len(list) == 0 {
+			replyText(message, "👥 Xodimlar ro'yxati bo'sh")
+			return
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "👥 Xodimlar (%d ta):\n", len(list))
+		for _, member := range list {
+			fmt.Fprintf(&sb, "• %s", member.Name)
+			if member.Username != "" {
+				fmt.Fprintf(&sb, " (@%s)", member.Username)
+			}
+			fmt.Fprintf(&sb, " - %s, ID: %d", member.Role, member.UserID)
+			if len(member.Countries) > 0 {
+				fmt.Fprintf(&sb, " [%s]", strings.Join(member.Countries, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		replyText(message, sb.String())
+
+	case "add":
+		if !canManageStaff(message.From) {
+			replyText(message, "⛔ Xodimlarni faqat menejerlar boshqaradi")
+			return
+		}
+		// /staff add <rol> (xodim xabariga reply qilib) yoki /staff add <user_id> <rol> [ism]
+		var member StaffMember
+		var roleArg string
+		var nameArgs []string
+		if reply := message.ReplyToMessage; reply != nil && reply.From != nil && len(args) >= 2 && len(args) <= 3 {
+			if _, err := strconv.ParseInt(args[1], 10, 64); err != nil {
+				member = StaffMember{UserID: reply.From.ID, Name: displayName(reply.From), Username: reply.From.UserName}
+				roleArg = args[1]
+				nameArgs = args[2:]
+			}
+		}
+		if member.UserID == 0 {
+			if len(args) < 3 {
+				replyText(message, "❗ Foydalanish: /staff add <user_id> <rol> [ism] yoki xodim xabariga reply qilib /staff add <rol>")
+				return
+			}
+			userID, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || userID <= 0 {
+				replyText(message, "❗ User ID musbat son bo'lishi kerak")
+				return
+			}
+			member = StaffMember{UserID: userID}
+			if existing, ok := staff.Get(userID); ok {
+				member = existing
+			}
+			roleArg = args[2]
+			nameArgs = args[3:]
+		}
+
+		role, ok := parseStaffRole(roleArg)
+		if !ok {
+			replyText(message, "❗ Rol: manager, consultant yoki viewer")
+			return
+		}
+		member.Role = role
+		if len(nameArgs) > 0 {
+			member.Name = strings.Join(nameArgs, " ")
+		}
+		if member.Name == "" {
+			member.Name = strconv.FormatInt(member.UserID, 10)
+		}
+		member.AddedBy = message.From.ID
+		member.AddedAt = time.Now()
+
+		if err := staff.Put(member); err != nil {
+			log.Printf("❌ Xodimni saqlashda xato: %v", err)
+			replyText(message, "❌ Xodimni saqlab bo'lmadi")
+			return
+		}
+		log.Printf("➕ Xodim qo'shildi: %s (ID: %d) - %s", member.Name, member.UserID, member.Role)
+		replyText(message, fmt.Sprintf("✅ %s (ID: %d) → %s", member.Name, member.UserID, member.Role))
+
+	case "countries":
+		if !canManageStaff(message.From) {
+			replyText(message, "⛔ Xodimlarni faqat menejerlar boshqaradi")
+			return
+		}
+		// /staff countries 123 UK, Schengen - bo'sh ro'yxat barcha davlatlarni bildiradi
+		if len(args) < 2 {
+			replyText(message, "❗ Foydalanish: /staff countries <user_id> [davlat, davlat...]")
+			return
+		}
+		userID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			replyText(message, "❗ User ID son bo'lishi kerak")
+			return
+		}
+		member, ok := staff.Get(userID)
+		if !ok {
+			replyText(message, fmt.Sprintf("❓ ID %d ro'yxatda yo'q", userID))
+			return
+		}
+		member.Countries = nil
+		for _, c := range strings.Split(strings.Join(args[2:], " "), ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				member.Countries = append(member.Countries, c)
+			}
+		}
+		if err := staff.Put(member); err != nil {
+			log.Printf("❌ Xodimni saqlashda xato: %v", err)
+			replyText(message, "❌ Xodimni saqlab bo'lmadi")
+			return
+		}
+		if len(member.Countries) == 0 {
+			replyText(message, fmt.Sprintf("✅ %s barcha davlatlarga mas'ul", member.Name))
+			return
+		}
+		replyText(message, fmt.Sprintf("✅ %s mas'ul: %s", member.Name, strings.Join(member.Countries, ", ")))
+
+	case "remove":
+		if !canManageStaff(message.From) {
+			replyText(message, "⛔ Xodimlarni faqat menejerlar boshqaradi")
+			return
+		}
+		var userID int64
+		if len(args) >= 2 {
+			id, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				replyText(message, "❗ User ID son bo'lishi kerak")
+				return
+			}
+			userID = id
+		} else if reply := message.ReplyToMessage; reply != nil && reply.From != nil {
+			userID = reply.From.ID
+		} else {
+			replyText(message, "❗ Foydalanish: /staff remove <user_id>")
+			return
+		}
+
+		removed, err := staff.Remove(userID)
+		if err != nil {
+			log.Printf("❌ Xodimni o'chirishda xato: %v", err)
+			replyText(message, "❌ Xodimni o'chirib bo'lmadi")
+			return
+		}
+		if !removed {
+			replyText(message, fmt.Sprintf("❓ ID %d ro'yxatda yo'q", userID))
+			return
+		}
+		log.Printf("➖ Xodim o'chirildi: ID %d", userID)
+		replyText(message, fmt.Sprintf("🗑️ ID %d ro'yxatdan o'chirildi", userID))
+
+	default:
+		replyText(message, staffUsage)
+	}
+}
+
+const staffUsage = `👥 Xodim buyruqlari:
+/staff list - barcha xodimlar
+/staff add <user_id> <rol> [ism] - xodim qo'shish yoki rolini o'zgartirish
+/staff add <rol> - xodim xabariga reply qilib qo'shish
+/staff countries <user_id> [davlat, ...] - mas'ul davlatlar (bo'sh - hammasi)
+/staff remove <user_id> - xodimni o'chirish
+Rollar: manager, consultant, viewer`
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Botning ish holati: ochiq xabarlar va kuzatilayotgan guruhlar.
+// Eslatma goroutine, update handlerlar va admin yangilash goroutinelari
+// bir vaqtda ishlaydi, shuning uchun barcha kirish mu orqali bo'ladi.
+// Tashqariga faqat nusxalar (clone) beriladi - o'zgartirish faqat
+// Update* metodlari ichida, lock ostida bajariladi.
+//
+// Xotirada faqat ochiq (pending/overdue) xabarlar turadi: eslatma loop,
+// dashboardlar, /pending va javobni aniqlash har daqiqada shu kichik
+// to'plamni ko'radi - har safar store ni (bolt da diskni) o'qimaslik uchun.
+// Yopilgan xabarlar faqat store da qoladi va kerak bo'lganda o'qiladi
+// (GetPending, QueryPending - /stats, /whois, ko'chirish)
+type BotState struct {
+	mu      sync.Mutex
+	store   Store
+	pending map[PendingKey]*PendingMessage // Faqat ochiq xabarlar
+	groups  map[int64]*GroupInfo
+}
+
+func newBotState(store Store) *BotState {
+	return &BotState{
+		store:   store,
+		pending: make(map[PendingKey]*PendingMessage),
+		groups:  make(map[int64]*GroupInfo),
+	}
+}
+
+// Store dan ochiq xabarlar va guruhlarni yuklash
+func (s *BotState) load() error {
+	var messages []*PendingMessage
+	for _, status := range []string{"pending", "overdue"} {
+		open, err := s.store.QueryPending(PendingQuery{Status: status})
+		if err != nil {
+			return err
+		}
+		messages = append(messages, open...)
+	}
+	groups, err := s.store.ListGroups()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	migrated := 0
+	for _, msg := range messages {
+		if msg.migrateSentMessageIDs(cfg.AdminChatID) {
+			s.savePendingLocked(msg)
+			migrated++
+		}
+		s.pending[msg.Key()] = msg
+	}
+	if migrated > 0 {
+		log.Printf("🔄 %d ta xabarning eslatma ID lari yangi formatga o'tkazildi", migrated)
+	}
+	for _, group := range groups {
+		s.groups[group.GroupID] = group
+	}
+
+	log.Printf("✅ %d ta javobsiz xabar yuklandi", len(s.pending))
+	log.Printf("✅ %d ta guruh ma'lumoti yuklandi", len(s.groups))
+	return nil
+}
+
+// Pending xabarni store ga saqlash (lock ostida chaqiriladi)
+func (s *BotState) savePendingLocked(msg *PendingMessage) {
+	if err := s.store.PutPending(msg); err != nil {
+		log.Printf("❌ Pending xabarni saqlashda xato (%s): %v", msg.Key(), err)
+	}
+}
+
+// Guruhni store ga saqlash (lock ostida chaqiriladi)
+func (s *BotState) saveGroupLocked(group *GroupInfo) {
+	if err := s.store.PutGroup(group); err != nil {
+		log.Printf("❌ Guruh ma'lumotini saqlashda xato (%d): %v", group.GroupID, err)
+		return
+	}
+
+	log.Printf("💾 Guruh ma'lumoti saqlandi: %s (ID: %d)", group.GroupTitle, group.GroupID)
+}
+
+// Yangi pending xabar qo'shish
+func (s *BotState) AddPending(msg *PendingMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[msg.Key()] = msg.clone()
+	s.savePendingLocked(msg)
+}
+
+// Xabarni topish (lock ostida chaqiriladi): ochiq xabar xotiradan,
+// yopilgani store dan o'qiladi
+func (s *BotState) lookupLocked(key PendingKey) (*PendingMessage, bool) {
+	if msg, exists := s.pending[key]; exists {
+		return msg, true
+	}
+	msg, err := s.store.GetPending(key)
+	if err != nil {
+		if err != ErrNotFound {
+			log.Printf("❌ Pending xabarni o'qishda xato (%s): %v", key, err)
+		}
+		return nil, false
+	}
+	return msg, true
+}
+
+// Xabarni saqlash va xotiradagi ochiq xabarlar to'plamini yangilash
+// (lock ostida chaqiriladi): yopilgan xabar xotiradan chiqariladi
+func (s *BotState) trackLocked(msg *PendingMessage) {
+	s.savePendingLocked(msg)
+	if msg.isOpen() {
+		s.pending[msg.Key()] = msg
+	} else {
+		delete(s.pending, msg.Key())
+	}
+}
+
+// Xabar nusxasini olish (ochiq yoki yopilgan)
+func (s *BotState) GetPending(key PendingKey) (*PendingMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, exists := s.lookupLocked(key)
+	if !exists {
+		return nil, false
+	}
+	return msg.clone(), true
+}
+
+// Xabarni lock ostida o'zgartirish. fn true qaytarsa xabar saqlanadi.
+// O'zgartirilgan xabar nusxasi qaytariladi
+func (s *BotState) UpdatePending(key PendingKey, fn func(msg *PendingMessage) bool) (*PendingMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, exists := s.lookupLocked(key)
+	if !exists {
+		return nil, false
+	}
+	if fn(msg) {
+		s.trackLocked(msg)
+	}
+	return msg.clone(), true
+}
+
+// Store dagi barcha (yopilganlari ham) xabarlardan so'rovga mos kelganlari
+// (vaqt bo'yicha tartiblangan). Xotiradagi ochiq xabarlar ham store ga
+// darhol yoziladi, shuning uchun natija to'liq
+func (s *BotState) QueryPending(q PendingQuery) ([]*PendingMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.store.QueryPending(q)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+// Shartga mos ochiq xabarlar nusxalari (vaqt bo'yicha tartiblangan)
+func (s *BotState) FindPending(match func(msg *PendingMessage) bool) []*PendingMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*PendingMessage
+	for _, msg := range s.pending {
+		if match(msg) {
+			result = append(result, msg.clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// Mijozning ochiq suhbatiga yangi xabar qo'shish. Shu guruhdagi shu
+// mijozning oxirgi xabari window ichida bo'lgan javobsiz suhbat topilsa
+// xabar unga qo'shiladi va nusxasi qaytariladi, aks holda false
+func (s *BotState) AppendToConversation(groupID, userID int64, part MessagePart, media *MessageMedia, window time.Duration) (*PendingMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var conversation *PendingMessage
+	for _, msg := range s.pending {
+		if msg.GroupID != groupID || msg.UserID != userID || msg.Status != "pending" {
+			continue
+		}
+		if part.Timestamp.Sub(msg.lastActivity()) > window {
+			continue
+		}
+		if conversation == nil || msg.lastActivity().After(conversation.lastActivity()) {
+			conversation = msg
+		}
+	}
+	if conversation == nil {
+		return nil, false
+	}
+
+	conversation.Parts = append(conversation.Parts, part)
+	if part.Text != "" {
+		if conversation.Text != "" {
+			conversation.Text += "\n"
+		}
+		conversation.Text += part.Text
+	}
+	if media != nil {
+		conversation.Media = append(conversation.Media, *media)
+	}
+	s.savePendingLocked(conversation)
+	return conversation.clone(), true
+}
+
+// Guruhdagi xabar qaysi ochiq suhbatga tegishli
+func (s *BotState) FindConversation(groupID int64, messageID int) (PendingKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, msg := range s.pending {
+		if msg.GroupID == groupID && msg.isOpen() && msg.containsMessage(messageID) {
+			return key, true
+		}
+	}
+	return PendingKey{}, false
+}
+
+// Ochiq xabarlar soni
+func (s *BotState) CountPending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending)
+}
+
+// Xabarni javob berilgan deb belgilash
+func (s *BotState) MarkAnswered(key PendingKey, answeredBy int64, answeredByName string) (*PendingMessage, bool) {
+	return s.CloseTicket(key, "answered", answeredBy, answeredByName)
+}
+
+// Xabarni yopish: "answered", "ignored" yoki "deleted". Eslatma kartalari xabardan
+// olib tashlanadi va qaytarilgan nusxada qoladi - ularni yopish lock dan
+// tashqarida bajariladi. Allaqachon yopilgan xabar o'zgarmaydi (false) -
+// eski kartadagi tugma yoki takroriy javob statistikani buzmasin
+func (s *BotState) CloseTicket(key PendingKey, status string, answeredBy int64, answeredByName string) (*PendingMessage, bool) {
+	return s.CloseTicketWithReason(key, status, answeredBy, answeredByName, "")
+}
+
+// Xabarni sabab bilan yopish (bot o'zi yopganda - kartada ko'rsatiladi)
+func (s *BotState) CloseTicketWithReason(key PendingKey, status string, answeredBy int64, answeredByName, reason string) (*PendingMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, exists := s.pending[key]
+	if !exists || !msg.isOpen() {
+		return nil, false
+	}
+
+	msg.Status = status
+	msg.AnsweredBy = answeredBy
+	msg.AnsweredByName = answeredByName
+	msg.AnsweredAt = time.Now()
+	msg.CloseReason = reason
+	result := msg.clone()
+	msg.SentReminders = nil
+
+	s.trackLocked(msg)
+	return result, true
+}
+
+// Yopilgan xabarni qayta ochish: eskalatsiya zinasi boshidan boshlanadi.
+// Eski kartalar xabardan olib tashlanadi (chaqiruvchi ularni yopadi)
+func (s *BotState) Reopen(key PendingKey) (*PendingMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, exists := s.lookupLocked(key)
+	if !exists {
+		return nil, false
+	}
+
+	msg.Status = "pending"
+	msg.AnsweredBy = 0
+	msg.AnsweredByName = ""
+	msg.AnsweredAt = time.Time{}
+	msg.CloseReason = ""
+	msg.EscalationLevel = 0
+	msg.AfterHoursLevel = 0
+	msg.AfterHoursFrom = time.Time{}
+	msg.ReopenedAt = time.Now()
+	msg.SnoozedUntil = time.Time{}
+	msg.SnoozedBy = ""
+	msg.SentReminders = nil
+
+	s.trackLocked(msg)
+	return msg.clone(), true
+}
+
+// Barcha guruhlar nusxalari
+func (s *BotState) ListGroups() []*GroupInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]*GroupInfo, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, group.clone())
+	}
+	return groups
+}
+
+// Guruh ma'lumoti nusxasini olish
+func (s *BotState) GetGroup(groupID int64) (*GroupInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, exists := s.groups[groupID]
+	if !exists {
+		return nil, false
+	}
+	return group.clone(), true
+}
+
+// Jami guruhlar soni
+func (s *BotState) CountGroups() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.groups)
+}
+
+// Chat ma'lumoti bo'yicha guruhni qo'shish yoki yangilash. Yangi guruh bo'lsa true,
+// nom o'zgargan bo'lsa eski nom ham qaytariladi (tarixga yoziladi)
+func (s *BotState) UpsertGroup(chat *tgbotapi.Chat) (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groupInfo, exists := s.groups[chat.ID]
+	if !exists {
+		groupInfo = &GroupInfo{
+			GroupID:    chat.ID,
+			GroupTitle: chat.Title,
+			GroupType:  chat.Type,
+			JoinedAt:   time.Now(),
+			IsActive:   true,
+			AdminIDs:   []int64{},
+		}
+		s.groups[chat.ID] = groupInfo
+	}
+
+	oldTitle := ""
+	if exists && chat.Title != "" && groupInfo.GroupTitle != chat.Title {
+		oldTitle = groupInfo.GroupTitle
+		groupInfo.TitleHistory = append(groupInfo.TitleHistory, GroupTitleChange{
+			OldTitle:  oldTitle,
+			NewTitle:  chat.Title,
+			ChangedAt: time.Now(),
+		})
+	}
+
+	// Guruh ma'lumotlarini yangilash
+	groupInfo.GroupTitle = chat.Title
+	groupInfo.GroupType = chat.Type
+	groupInfo.LastUpdated = time.Now()
+	groupInfo.IsActive = true
+
+	s.saveGroupLocked(groupInfo)
+	return !exists, oldTitle
+}
+
+// Guruhni yangi ID ga ko'chirish (oddiy guruh -> supergroup). Eski guruh
+// nofaol bo'lib qoladi, barcha xabarlari (yopilganlari ham - /stats va /whois
+// tarixi uchun) yangi kalit bilan qayta saqlanadi. Ko'chirilgan ochiq xabarlar
+// nusxalari qaytariladi; qayta chaqirilsa hech narsa qilmaydi
+func (s *BotState) MigrateGroup(oldID, newID int64) []*PendingMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if old, exists := s.groups[oldID]; exists && old.MigratedTo != newID {
+		group, migrated := s.groups[newID]
+		if !migrated {
+			group = old.clone()
+			group.GroupID = newID
+			group.GroupType = "supergroup"
+			s.groups[newID] = group
+		}
+		group.MigratedFrom = oldID
+		group.IsActive = true
+		group.LastUpdated = now
+		s.saveGroupLocked(group)
+
+		old.IsActive = false
+		old.MigratedTo = newID
+		old.LastUpdated = now
+		s.saveGroupLocked(old)
+	}
+
+	messages, err := s.store.QueryPending(PendingQuery{GroupID: oldID})
+	if err != nil {
+		log.Printf("❌ Guruh xabarlarini o'qishda xato (%d): %v", oldID, err)
+		return nil
+	}
+
+	var moved []*PendingMessage
+	for _, msg := range messages {
+		key := msg.Key()
+		if open, exists := s.pending[key]; exists {
+			msg = open
+		}
+		newKey := PendingKey{GroupID: newID, MessageID: msg.MessageID}
+		if _, taken := s.lookupLocked(newKey); taken {
+			log.Printf("⚠️ %s ni ko'chirib bo'lmadi: %s allaqachon mavjud", key, newKey)
+			continue
+		}
+		if err := s.store.DeletePending(key); err != nil {
+			log.Printf("❌ Pending xabarni o'chirishda xato (%s): %v", key, err)
+			continue
+		}
+		delete(s.pending, key)
+		msg.GroupID = newID
+		s.trackLocked(msg)
+		if msg.isOpen() {
+			moved = append(moved, msg.clone())
+		}
+	}
+	return moved
+}
+
+// Guruhni lock ostida o'zgartirish va saqlash
+func (s *BotState) UpdateGroup(groupID int64, fn func(group *GroupInfo)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, exists := s.groups[groupID]
+	if !exists {
+		return false
+	}
+	fn(group)
+	s.saveGroupLocked(group)
+	return true
+}
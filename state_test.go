@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Vaqtinchalik papkadagi JSON store ustida yangi BotState
+func newTestState(t *testing.T) *BotState {
+	t.Helper()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	dir := t.TempDir()
+	store, err := openJSONStore(filepath.Join(dir, "pending.json"), filepath.Join(dir, "groups.json"), 3, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s := newBotState(store)
+	if err := s.load(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestBotStateKeepsOnlyOpenTicketsInMemory(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		s.AddPending(&PendingMessage{GroupID: -1, MessageID: i, UserID: 10, Status: "pending", Timestamp: now.Add(time.Duration(i) * time.Minute)})
+	}
+
+	closedKey := PendingKey{GroupID: -1, MessageID: 2}
+	if _, ok := s.MarkAnswered(closedKey, 5, "Admin"); !ok {
+		t.Fatal("MarkAnswered false qaytardi")
+	}
+	if got := s.CountPending(); got != 2 {
+		t.Fatalf("xotirada %d ta xabar, kutilgan 2", got)
+	}
+	if open := s.FindPending(func(*PendingMessage) bool { return true }); len(open) != 2 {
+		t.Fatalf("FindPending %d ta xabar qaytardi, kutilgan 2", len(open))
+	}
+
+	// Yopilgan xabar store dan o'qiladi
+	msg, ok := s.GetPending(closedKey)
+	if !ok || msg.Status != "answered" || msg.AnsweredByName != "Admin" {
+		t.Fatalf("GetPending = %+v, %v", msg, ok)
+	}
+	if _, ok := s.MarkAnswered(closedKey, 6, "Boshqa"); ok {
+		t.Fatal("yopilgan xabar qayta yopildi")
+	}
+	history, err := s.QueryPending(PendingQuery{UserID: 10})
+	if err != nil || len(history) != 3 {
+		t.Fatalf("QueryPending %d ta xabar (%v), kutilgan 3", len(history), err)
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i].Timestamp.Before(history[i-1].Timestamp) {
+			t.Fatal("QueryPending natijasi vaqt bo'yicha tartiblanmagan")
+		}
+	}
+
+	// Qayta ochilgan xabar yana xotiraga qaytadi
+	if _, ok := s.Reopen(closedKey); !ok {
+		t.Fatal("Reopen false qaytardi")
+	}
+	if got := s.CountPending(); got != 3 {
+		t.Fatalf("qayta ochilgandan keyin xotirada %d ta xabar, kutilgan 3", got)
+	}
+
+	// Qayta yuklanganda faqat ochiq xabarlar xotiraga olinadi
+	s.CloseTicket(PendingKey{GroupID: -1, MessageID: 3}, "ignored", 5, "Admin")
+	reloaded := newBotState(s.store)
+	if err := reloaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.CountPending(); got != 2 {
+		t.Fatalf("qayta yuklangandan keyin xotirada %d ta xabar, kutilgan 2", got)
+	}
+}
+
+func TestMigrateGroupMovesClosedTickets(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+	s.AddPending(&PendingMessage{GroupID: -1, MessageID: 1, Status: "pending", Timestamp: now})
+	s.AddPending(&PendingMessage{GroupID: -1, MessageID: 2, Status: "pending", Timestamp: now})
+	s.MarkAnswered(PendingKey{GroupID: -1, MessageID: 2}, 5, "Admin")
+
+	moved := s.MigrateGroup(-1, -1002)
+	if len(moved) != 1 || moved[0].Key() != (PendingKey{GroupID: -1002, MessageID: 1}) {
+		t.Fatalf("ko'chirilgan ochiq xabarlar: %v", keysOf(moved))
+	}
+	if msg, ok := s.GetPending(PendingKey{GroupID: -1002, MessageID: 2}); !ok || msg.Status != "answered" {
+		t.Fatalf("yopilgan xabar ko'chirilmadi: %+v, %v", msg, ok)
+	}
+	if old, _ := s.QueryPending(PendingQuery{GroupID: -1}); len(old) != 0 {
+		t.Fatalf("eski guruhda %d ta xabar qoldi", len(old))
+	}
+	if again := s.MigrateGroup(-1, -1002); len(again) != 0 {
+		t.Fatalf("qayta ko'chirishda %d ta xabar ko'chdi", len(again))
+	}
+}
+
+// Eslatma loop (FindPending + UpdatePending) handlerlar bilan bir vaqtda
+// ishlaganda race bo'lmasligi kerak. go test -race bilan ishga tushiriladi
+func TestBotStateConcurrentAccess(t *testing.T) {
+	const (
+		groupID  = int64(-100)
+		messages = 40
+	)
+
+	tests := []struct {
+		name   string
+		handle func(s *BotState, i int)
+	}{
+		{"MarkAnswered", func(s *BotState, i int) {
+			s.MarkAnswered(PendingKey{GroupID: groupID, MessageID: i}, 5, "Admin")
+		}},
+		{"CloseTicket", func(s *BotState, i int) {
+			s.CloseTicket(PendingKey{GroupID: groupID, MessageID: i}, "ignored", 5, "Admin")
+		}},
+		{"AppendToConversation", func(s *BotState, i int) {
+			part := MessagePart{MessageID: 1000 + i, Text: "yana", Timestamp: time.Now()}
+			s.AppendToConversation(groupID, int64(i%5), part, nil, time.Hour)
+		}},
+		{"MigrateGroup", func(s *BotState, i int) {
+			if i%10 == 0 {
+				s.MigrateGroup(groupID, groupID-int64(i))
+			}
+		}},
+		{"UpsertGroup va UpdateGroup", func(s *BotState, i int) {
+			s.UpsertGroup(&tgbotapi.Chat{ID: groupID, Title: fmt.Sprintf("Guruh %d", i%3), Type: "supergroup"})
+			s.UpdateGroup(groupID, func(group *GroupInfo) {
+				group.AdminIDs = append(group.AdminIDs, int64(i))
+			})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestState(t)
+			now := time.Now()
+			s.UpsertGroup(&tgbotapi.Chat{ID: groupID, Title: "Guruh", Type: "supergroup"})
+			for i := 0; i < messages; i++ {
+				s.AddPending(&PendingMessage{GroupID: groupID, MessageID: i, UserID: int64(i % 5), Status: "pending", Timestamp: now})
+			}
+
+			var wg sync.WaitGroup
+			stop := make(chan struct{})
+
+			// Eslatma loop: checkAndSendReminders dagi kabi nusxalarni o'qib yangilaydi
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					for _, msg := range s.FindPending(func(msg *PendingMessage) bool { return msg.Status == "pending" }) {
+						msg.ReminderCount++ // Nusxani o'zgartirish state ga ta'sir qilmasligi kerak
+						s.UpdatePending(msg.Key(), func(m *PendingMessage) bool {
+							if m.Status != "pending" {
+								return false
+							}
+							m.LastReminder = time.Now()
+							m.ReminderCount++
+							m.SentReminders = append(m.SentReminders, SentReminder{ChatID: 1, MessageID: m.ReminderCount})
+							return true
+						})
+					}
+					s.ListGroups()
+				}
+			}()
+
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := w; i < messages; i += 4 {
+						tt.handle(s, i)
+					}
+				}(w)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			close(stop)
+			wg.Wait()
+
+			for _, msg := range s.FindPending(func(*PendingMessage) bool { return true }) {
+				if !msg.isOpen() {
+					t.Errorf("%s yopilgan, lekin xotirada qoldi", msg.Key())
+				}
+			}
+		})
+	}
+}
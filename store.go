@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Saqlash backendlari
+const (
+	STORE_BACKEND_JSON = "json"
+	STORE_BACKEND_BOLT = "bolt"
+)
+
+// Yozuv topilmaganda qaytariladigan xato
+var ErrNotFound = errors.New("yozuv topilmadi")
+
+// Store - pending xabarlar va guruhlarni saqlash qatlami.
+// JSON fayllar va bitta faylli embedded baza (bbolt) shu interfeysni amalga oshiradi
+type Store interface {
+	GetPending(key PendingKey) (*PendingMessage, error)
+	PutPending(msg *PendingMessage) error
+	DeletePending(key PendingKey) error
+	ListPending() ([]*PendingMessage, error)
+	QueryPending(q PendingQuery) ([]*PendingMessage, error)
+
+	GetGroup(groupID int64) (*GroupInfo, error)
+	PutGroup(group *GroupInfo) error
+	ListGroups() ([]*GroupInfo, error)
+
+	Close() error
+}
+
+// Pending xabarlar bo'yicha so'rov. Bo'sh maydonlar filtr sifatida ishlatilmaydi
+type PendingQuery struct {
+	GroupID   int64     // 0 - barcha guruhlar
+	UserID    int64     // 0 - barcha mijozlar
+	Status    string    // "" - barcha statuslar
+	OlderThan time.Time // nol bo'lmasa - faqat shu vaqtdan oldin kelgan xabarlar
+	NewerThan time.Time // nol bo'lmasa - faqat shu vaqtdan (shu vaqt ham) keyin kelgan xabarlar
+}
+
+// Xabar so'rov shartlariga mos kelishini tekshirish
+func (q PendingQuery) matches(msg *PendingMessage) bool {
+	if q.GroupID != 0 && msg.GroupID != q.GroupID {
+		return false
+	}
+	if q.UserID != 0 && msg.UserID != q.UserID {
+		return false
+	}
+	if q.Status != "" && msg.Status != q.Status {
+		return false
+	}
+	if !q.NewerThan.IsZero() && msg.Timestamp.Before(q.NewerThan) {
+		return false
+	}
+	if !q.OlderThan.IsZero() && !msg.Timestamp.Before(q.OlderThan) {
+		return false
+	}
+	return true
+}
+
+// Konfiguratsiya bo'yicha store ochish
+func openStore(c *Config) (Store, error) {
+	switch c.StoreBackend {
+	case "", STORE_BACKEND_JSON:
+		return openJSONStoreFromConfig(c)
+	case STORE_BACKEND_BOLT:
+		boltFile := c.dataPath(c.BoltFile)
+		_, statErr := os.Stat(boltFile)
+		s, err := openBoltStore(boltFile)
+		if err != nil {
+			return nil, err
+		}
+		// Baza birinchi marta yaratilgan bo'lsa - mavjud JSON fayllardan import qilish
+		if os.IsNotExist(statErr) {
+			if err := importFromJSON(c, s); err != nil {
+				s.Close()
+				return nil, err
+			}
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("noma'lum store backend: %q (json yoki bolt bo'lishi kerak)", c.StoreBackend)
+	}
+}
+
+// JSON fayllardagi ma'lumotlarni boshqa store ga ko'chirish
+func importFromJSON(c *Config, dst Store) error {
+	src, err := openJSONStoreFromConfig(c)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	messages, err := src.ListPending()
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := dst.PutPending(msg); err != nil {
+			return err
+		}
+	}
+
+	groups, err := src.ListGroups()
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := dst.PutGroup(group); err != nil {
+			return err
+		}
+	}
+
+	if len(messages) > 0 || len(groups) > 0 {
+		log.Printf("📥 JSON fayllardan import qilindi: %d ta xabar, %d ta guruh", len(messages), len(groups))
+	}
+	return nil
+}
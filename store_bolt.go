@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt bazasidagi bucketlar
+var (
+	bucketPending       = []byte("pending")
+	bucketPendingByTime = []byte("pending_by_time")
+	bucketGroups        = []byte("groups")
+)
+
+// Bitta faylli embedded baza (bbolt) ga asoslangan store.
+// Pending xabarlar kaliti guruh ID + xabar ID (big-endian), shuning uchun
+// bitta guruhning xabarlari ketma-ket joylashadi va prefix bo'yicha o'qiladi.
+// pending_by_time indeksi esa "N daqiqadan eski" so'rovlarini butun bazani
+// o'qimasdan bajarish uchun
+type boltStore struct {
+	db *bolt.DB
+}
+
+// Bolt bazasini ochish va bucketlarni yaratish
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketPending, bucketPendingByTime, bucketGroups} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+// Guruh ID ni tartiblanadigan 8 baytga o'girish (manfiy ID lar ham to'g'ri tartiblanadi)
+func groupKeyBytes(groupID int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(groupID)^(1<<63))
+	return b
+}
+
+func groupIDFromBytes(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63))
+}
+
+// Pending xabar kaliti: 8 bayt guruh + 4 bayt xabar ID
+func pendingKeyBytes(key PendingKey) []byte {
+	b := make([]byte, 12)
+	copy(b, groupKeyBytes(key.GroupID))
+	binary.BigEndian.PutUint32(b[8:], uint32(key.MessageID))
+	return b
+}
+
+// Vaqt indeksi kaliti: 8 bayt vaqt (unix nano) + pending kaliti
+func timeIndexKey(ts time.Time, key PendingKey) []byte {
+	b := make([]byte, 8, 20)
+	binary.BigEndian.PutUint64(b, uint64(ts.UnixNano())^(1<<63))
+	return append(b, pendingKeyBytes(key)...)
+}
+
+func (s *boltStore) GetPending(key PendingKey) (*PendingMessage, error) {
+	var msg *PendingMessage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketPending).Get(pendingKeyBytes(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		msg = &PendingMessage{}
+		return json.Unmarshal(data, msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *boltStore) PutPending(msg *PendingMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(bucketPending)
+		index := tx.Bucket(bucketPendingByTime)
+		key := pendingKeyBytes(msg.Key())
+
+		// Vaqt o'zgargan bo'lsa eski indeks yozuvini o'chirish
+		if old := pending.Get(key); old != nil {
+			var oldMsg PendingMessage
+			if err := json.Unmarshal(old, &oldMsg); err == nil && !oldMsg.Timestamp.Equal(msg.Timestamp) {
+				if err := index.Delete(timeIndexKey(oldMsg.Timestamp, oldMsg.Key())); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := pending.Put(key, data); err != nil {
+			return err
+		}
+		return index.Put(timeIndexKey(msg.Timestamp, msg.Key()), key)
+	})
+}
+
+func (s *boltStore) DeletePending(key PendingKey) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(bucketPending)
+		k := pendingKeyBytes(key)
+
+		old := pending.Get(k)
+		if old == nil {
+			return nil
+		}
+		var oldMsg PendingMessage
+		if err := json.Unmarshal(old, &oldMsg); err == nil {
+			if err := tx.Bucket(bucketPendingByTime).Delete(timeIndexKey(oldMsg.Timestamp, key)); err != nil {
+				return err
+			}
+		}
+		return pending.Delete(k)
+	})
+}
+
+func (s *boltStore) ListPending() ([]*PendingMessage, error) {
+	return s.QueryPending(PendingQuery{})
+}
+
+// So'rov bo'yicha xabarlarni o'qish. Guruh berilgan bo'lsa faqat shu guruh
+// prefiksi, aks holda vaqt indeksi bo'yicha NewerThan dan OlderThan gacha o'qiladi
+func (s *boltStore) QueryPending(q PendingQuery) ([]*PendingMessage, error) {
+	var result []*PendingMessage
+
+	decode := func(data []byte) error {
+		msg := &PendingMessage{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			return err
+		}
+		if q.matches(msg) {
+			result = append(result, msg)
+		}
+		return nil
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(bucketPending)
+
+		if q.GroupID != 0 {
+			prefix := groupKeyBytes(q.GroupID)
+			c := pending.Cursor()
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				if err := decode(v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var limit []byte
+		if !q.OlderThan.IsZero() {
+			limit = timeIndexKey(q.OlderThan, PendingKey{})[:8]
+		}
+		c := tx.Bucket(bucketPendingByTime).Cursor()
+		k, v := c.First()
+		if !q.NewerThan.IsZero() {
+			k, v = c.Seek(timeIndexKey(q.NewerThan, PendingKey{})[:8])
+		}
+		for ; k != nil; k, v = c.Next() {
+			if limit != nil && bytes.Compare(k[:8], limit) >= 0 {
+				break
+			}
+			if err := decode(pending.Get(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *boltStore) GetGroup(groupID int64) (*GroupInfo, error) {
+	var group *GroupInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketGroups).Get(groupKeyBytes(groupID))
+		if data == nil {
+			return ErrNotFound
+		}
+		group = &GroupInfo{}
+		return json.Unmarshal(data, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *boltStore) PutGroup(group *GroupInfo) error {
+	data, err := json.Marshal(group)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketGroups).Put(groupKeyBytes(group.GroupID), data)
+	})
+}
+
+func (s *boltStore) ListGroups() ([]*GroupInfo, error) {
+	var result []*GroupInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketGroups).ForEach(func(k, v []byte) error {
+			group := &GroupInfo{}
+			if err := json.Unmarshal(v, group); err != nil {
+				return err
+			}
+			if group.GroupID == 0 {
+				group.GroupID = groupIDFromBytes(k)
+			}
+			result = append(result, group)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
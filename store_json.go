@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type PendingMessagesData struct {
+	Messages map[string]*PendingMessage `json:"messages"`
+}
+
+type GroupsData struct {
+	Groups map[string]*GroupInfo `json:"groups"`
+}
+
+// JSON fayllarga asoslangan store. Har bir yozishda butun fayl vaqtinchalik
+// fayl orqali atomik qayta yoziladi va vaqti-vaqti bilan backup olinadi
+type jsonStore struct {
+	mu          sync.Mutex
+	pendingFile string
+	groupsFile  string
+	backupKeep  int
+	backupEvery time.Duration
+	pending     map[PendingKey]*PendingMessage
+	groups      map[int64]*GroupInfo
+}
+
+// Sozlamalardagi fayl yo'llari bilan JSON store ochish
+func openJSONStoreFromConfig(c *Config) (*jsonStore, error) {
+	return openJSONStore(c.dataPath(c.PendingFile), c.dataPath(c.GroupsFile), c.BackupKeep, c.BackupInterval.Duration)
+}
+
+// JSON store ochish va fayllardan ma'lumotlarni yuklash
+func openJSONStore(pendingFile, groupsFile string, backupKeep int, backupEvery time.Duration) (*jsonStore, error) {
+	s := &jsonStore{
+		pendingFile: pendingFile,
+		groupsFile:  groupsFile,
+		backupKeep:  backupKeep,
+		backupEvery: backupEvery,
+		pending:     make(map[PendingKey]*PendingMessage),
+		groups:      make(map[int64]*GroupInfo),
+	}
+	if err := s.loadPending(); err != nil {
+		return nil, err
+	}
+	if err := s.loadGroups(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// JSON fayldan pending messages yuklash. Fayl buzilgan bo'lsa eng yangi
+// yaroqli backup ishlatiladi; yaroqli backup ham bo'lmasa xato qaytariladi,
+// aks holda bo'sh ma'lumot keyingi yozishda barcha kuzatuvni o'chirib yuboradi
+func (s *jsonStore) loadPending() error {
+	if _, err := os.Stat(s.pendingFile); os.IsNotExist(err) {
+		log.Printf("📁 Pending messages fayli mavjud emas: %s", s.pendingFile)
+		return nil
+	}
+
+	var pendingData PendingMessagesData
+	source, err := readJSONWithBackup(s.pendingFile, &pendingData)
+	if err != nil {
+		return fmt.Errorf("pending messages yuklanmadi: %w", err)
+	}
+
+	migrated := 0
+	for keyStr, msg := range pendingData.Messages {
+		key, err := parsePendingKey(keyStr)
+		if err != nil {
+			// Eski format: kalit faqat message ID edi - kalitni xabarning o'zidan tiklash
+			key = msg.Key()
+			migrated++
+		}
+		s.pending[key] = msg
+	}
+
+	if source != s.pendingFile {
+		log.Printf("♻️ Pending messages backupdan yuklandi: %s (%d ta xabar)", source, len(s.pending))
+	}
+
+	if migrated > 0 {
+		log.Printf("🔄 %d ta xabar kaliti yangi formatga (guruh:xabar) o'tkazildi", migrated)
+		return s.writePending()
+	}
+	return nil
+}
+
+// JSON fayldan guruhlar ma'lumotini yuklash (buzilgan bo'lsa backupdan)
+func (s *jsonStore) loadGroups() error {
+	if _, err := os.Stat(s.groupsFile); os.IsNotExist(err) {
+		log.Printf("📁 Guruhlar fayli mavjud emas: %s", s.groupsFile)
+		return nil
+	}
+
+	var groupsData GroupsData
+	source, err := readJSONWithBackup(s.groupsFile, &groupsData)
+	if err != nil {
+		return fmt.Errorf("guruhlar yuklanmadi: %w", err)
+	}
+
+	for groupIDStr, group := range groupsData.Groups {
+		groupID, _ := strconv.ParseInt(groupIDStr, 10, 64)
+		s.groups[groupID] = group
+	}
+
+	if source != s.groupsFile {
+		log.Printf("♻️ Guruhlar backupdan yuklandi: %s (%d ta guruh)", source, len(s.groups))
+	}
+	return nil
+}
+
+// JSON faylga pending messages saqlash
+func (s *jsonStore) writePending() error {
+	pendingData := PendingMessagesData{
+		Messages: make(map[string]*PendingMessage),
+	}
+
+	for key, msg := range s.pending {
+		pendingData.Messages[key.String()] = msg
+	}
+
+	data, err := json.MarshalIndent(pendingData, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rotateBackup(s.pendingFile, s.backupKeep, s.backupEvery)
+	return writeFileAtomic(s.pendingFile, data, 0644)
+}
+
+// JSON faylga guruhlar ma'lumotini saqlash
+func (s *jsonStore) writeGroups() error {
+	groupsData := GroupsData{
+		Groups: make(map[string]*GroupInfo),
+	}
+
+	for groupID, group := range s.groups {
+		groupsData.Groups[strconv.FormatInt(groupID, 10)] = group
+	}
+
+	data, err := json.MarshalIndent(groupsData, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rotateBackup(s.groupsFile, s.backupKeep, s.backupEvery)
+	return writeFileAtomic(s.groupsFile, data, 0644)
+}
+
+func (s *jsonStore) GetPending(key PendingKey) (*PendingMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, exists := s.pending[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return msg.clone(), nil
+}
+
+func (s *jsonStore) PutPending(msg *PendingMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[msg.Key()] = msg.clone()
+	return s.writePending()
+}
+
+func (s *jsonStore) DeletePending(key PendingKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.pending[key]; !exists {
+		return nil
+	}
+	delete(s.pending, key)
+	return s.writePending()
+}
+
+func (s *jsonStore) ListPending() ([]*PendingMessage, error) {
+	return s.QueryPending(PendingQuery{})
+}
+
+func (s *jsonStore) QueryPending(q PendingQuery) ([]*PendingMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*PendingMessage
+	for _, msg := range s.pending {
+		if q.matches(msg) {
+			result = append(result, msg.clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+func (s *jsonStore) GetGroup(groupID int64) (*GroupInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, exists := s.groups[groupID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return group.clone(), nil
+}
+
+func (s *jsonStore) PutGroup(group *GroupInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[group.GroupID] = group.clone()
+	return s.writeGroups()
+}
+
+func (s *jsonStore) ListGroups() ([]*GroupInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*GroupInfo, 0, len(s.groups))
+	for _, group := range s.groups {
+		result = append(result, group.clone())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GroupID < result[j].GroupID
+	})
+	return result, nil
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Ikkala backend uchun bir xil testlar
+func storeBackends(t *testing.T) map[string]func() Store {
+	t.Helper()
+	dir := t.TempDir()
+	return map[string]func() Store{
+		STORE_BACKEND_JSON: func() Store {
+			s, err := openJSONStore(filepath.Join(dir, "pending.json"), filepath.Join(dir, "groups.json"), 3, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		STORE_BACKEND_BOLT: func() Store {
+			s, err := openBoltStore(filepath.Join(dir, "bot.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+}
+
+func keysOf(messages []*PendingMessage) []PendingKey {
+	keys := make([]PendingKey, 0, len(messages))
+	for _, msg := range messages {
+		keys = append(keys, msg.Key())
+	}
+	return keys
+}
+
+func sameKeys(got []*PendingMessage, want ...PendingKey) bool {
+	keys := keysOf(got)
+	if len(keys) != len(want) {
+		return false
+	}
+	seen := make(map[PendingKey]bool)
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for _, key := range want {
+		if !seen[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStorePendingCRUD(t *testing.T) {
+	for name, open := range storeBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			key := PendingKey{GroupID: -100, MessageID: 7}
+
+			if _, err := s.GetPending(key); err != ErrNotFound {
+				t.Fatalf("bo'sh store: xato %v, kutilgan ErrNotFound", err)
+			}
+
+			msg := &PendingMessage{GroupID: key.GroupID, MessageID: key.MessageID, Text: "salom", Status: "pending", Timestamp: time.Now()}
+			if err := s.PutPending(msg); err != nil {
+				t.Fatal(err)
+			}
+			msg.Text = "o'zgardi" // Store nusxani saqlashi kerak
+			got, err := s.GetPending(key)
+			if err != nil || got.Text != "salom" {
+				t.Fatalf("GetPending = %+v, %v", got, err)
+			}
+
+			got.Status = "answered"
+			if err := s.PutPending(got); err != nil {
+				t.Fatal(err)
+			}
+			all, err := s.ListPending()
+			if err != nil || len(all) != 1 || all[0].Status != "answered" {
+				t.Fatalf("ListPending = %v, %v", keysOf(all), err)
+			}
+
+			if err := s.DeletePending(key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.GetPending(key); err != ErrNotFound {
+				t.Fatalf("o'chirilgandan keyin xato %v, kutilgan ErrNotFound", err)
+			}
+
+			// Qayta ochilganda ma'lumot saqlanib qolishi kerak
+			if err := s.PutPending(msg); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			s = open()
+			defer s.Close()
+			if got, err := s.GetPending(key); err != nil || got.Text != "o'zgardi" {
+				t.Fatalf("qayta ochilgandan keyin GetPending = %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestStoreQueryPending(t *testing.T) {
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	a := &PendingMessage{GroupID: -1, MessageID: 1, UserID: 10, Status: "answered", Timestamp: base}
+	b := &PendingMessage{GroupID: -1, MessageID: 2, UserID: 11, Status: "pending", Timestamp: base.Add(time.Hour)}
+	c := &PendingMessage{GroupID: -2, MessageID: 1, UserID: 10, Status: "pending", Timestamp: base.Add(2 * time.Hour)}
+	d := &PendingMessage{GroupID: -2, MessageID: 3, UserID: 12, Status: "overdue", Timestamp: base.Add(3 * time.Hour)}
+
+	tests := []struct {
+		name  string
+		query PendingQuery
+		want  []PendingKey
+	}{
+		{"hammasi", PendingQuery{}, []PendingKey{a.Key(), b.Key(), c.Key(), d.Key()}},
+		{"guruh", PendingQuery{GroupID: -1}, []PendingKey{a.Key(), b.Key()}},
+		{"status", PendingQuery{Status: "pending"}, []PendingKey{b.Key(), c.Key()}},
+		{"mijoz", PendingQuery{UserID: 10}, []PendingKey{a.Key(), c.Key()}},
+		{"dan oldin", PendingQuery{OlderThan: base.Add(2 * time.Hour)}, []PendingKey{a.Key(), b.Key()}},
+		{"dan keyin (chegara ham)", PendingQuery{NewerThan: base.Add(2 * time.Hour)}, []PendingKey{c.Key(), d.Key()}},
+		{"oraliq", PendingQuery{NewerThan: base.Add(30 * time.Minute), OlderThan: base.Add(3 * time.Hour)}, []PendingKey{b.Key(), c.Key()}},
+		{"guruh va vaqt", PendingQuery{GroupID: -2, NewerThan: base.Add(150 * time.Minute)}, []PendingKey{d.Key()}},
+		{"mos yo'q", PendingQuery{GroupID: -3}, nil},
+	}
+
+	for name, open := range storeBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			defer s.Close()
+			for _, msg := range []*PendingMessage{d, b, a, c} {
+				if err := s.PutPending(msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Vaqt o'zgarsa bolt vaqt indeksi ham yangilanishi kerak
+			moved := d.clone()
+			moved.Timestamp = base.Add(4 * time.Hour)
+			if err := s.PutPending(moved); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tt := range tests {
+				got, err := s.QueryPending(tt.query)
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+				if !sameKeys(got, tt.want...) {
+					t.Errorf("%s: %v, kutilgan %v", tt.name, keysOf(got), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreGroups(t *testing.T) {
+	for name, open := range storeBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			defer s.Close()
+
+			if _, err := s.GetGroup(-5); err != ErrNotFound {
+				t.Fatalf("bo'sh store: xato %v, kutilgan ErrNotFound", err)
+			}
+			for _, group := range []*GroupInfo{{GroupID: -5, GroupTitle: "Birinchi"}, {GroupID: -6, GroupTitle: "Ikkinchi"}, {GroupID: -5, GroupTitle: "Yangi nom"}} {
+				if err := s.PutGroup(group); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := s.GetGroup(-5)
+			if err != nil || got.GroupTitle != "Yangi nom" {
+				t.Fatalf("GetGroup = %+v, %v", got, err)
+			}
+			groups, err := s.ListGroups()
+			if err != nil || len(groups) != 2 {
+				t.Fatalf("ListGroups: %d ta (%v), kutilgan 2", len(groups), err)
+			}
+		})
+	}
+}
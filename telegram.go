@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// tgbotapi v5.5.1 forum topiclarini bilmaydi (message_thread_id yo'q),
+// shuning uchun topicga oid so'rovlar MakeRequest orqali qo'lda yuboriladi
+
+// Forum topicga yuboriladigan matnli xabar
+type ThreadMessageConfig struct {
+	ChatID                int64
+	MessageThreadID       int // 0 - General topic (yoki oddiy guruh)
+	Text                  string
+	ParseMode             string
+	ReplyToMessageID      int
+	ReplyMarkup           interface{}
+	DisableWebPagePreview bool
+}
+
+func (c ThreadMessageConfig) params() (tgbotapi.Params, error) {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", c.ChatID)
+	params.AddNonZero("message_thread_id", c.MessageThreadID)
+	params.AddNonEmpty("text", c.Text)
+	params.AddNonEmpty("parse_mode", c.ParseMode)
+	params.AddNonZero("reply_to_message_id", c.ReplyToMessageID)
+	params.AddBool("disable_web_page_preview", c.DisableWebPagePreview)
+	if c.ReplyToMessageID != 0 {
+		params.AddBool("allow_sending_without_reply", true)
+	}
+	if err := params.AddInterface("reply_markup", c.ReplyMarkup); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// API javobini tgbotapi.Message ga o'girish
+func decodeMessage(resp *tgbotapi.APIResponse) (tgbotapi.Message, error) {
+	var message tgbotapi.Message
+	err := json.Unmarshal(resp.Result, &message)
+	return message, err
+}
+
+// Xabarni message_thread_id bilan yuborish
+func sendThreadMessage(c ThreadMessageConfig) (tgbotapi.Message, error) {
+	params, err := c.params()
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	resp, err := bot.MakeRequest("sendMessage", params)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return decodeMessage(resp)
+}
+
+// Topic yopilgan, o'chirilgan yoki mavjud emasligini bildiruvchi xato
+func isThreadError(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	msg := strings.ToUpper(apiErr.Message)
+	for _, marker := range []string{"THREAD NOT FOUND", "TOPIC_CLOSED", "TOPIC_DELETED", "TOPIC_ID_INVALID", "MESSAGE THREAD"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Xabarni topicga yuborish. Topic yopilgan yoki topilmasa xabar
+// sozlamalardagi umumiy (General) topicga yuboriladi.
+// Haqiqatda yuborilgan thread ID ham qaytariladi
+func sendToTopic(c ThreadMessageConfig) (tgbotapi.Message, int, error) {
+	sent, err := sendThreadMessage(c)
+	if err == nil {
+		return sent, c.MessageThreadID, nil
+	}
+	if !isThreadError(err) || c.MessageThreadID == cfg.GeneralThreadID {
+		return sent, c.MessageThreadID, err
+	}
+
+	log.Printf("⚠️ Topic %d ga yuborib bo'lmadi (%v), umumiy topicga yuboriladi", c.MessageThreadID, err)
+	c.MessageThreadID = cfg.GeneralThreadID
+	sent, err = sendThreadMessage(c)
+	return sent, c.MessageThreadID, err
+}
+
+// Forum topic ma'lumoti (createForumTopic javobi)
+type ForumTopic struct {
+	MessageThreadID int    `json:"message_thread_id"`
+	Name            string `json:"name"`
+	IconColor       int    `json:"icon_color"`
+}
+
+// Forum guruhida yangi topic yaratish. Bot "Manage topics" huquqiga ega bo'lishi kerak
+func createForumTopic(chatID int64, name string) (ForumTopic, error) {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonEmpty("name", name)
+
+	var topic ForumTopic
+	resp, err := bot.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return topic, err
+	}
+	err = json.Unmarshal(resp.Result, &topic)
+	return topic, err
+}
+
+// Xabarlarning forum thread ID lari. tgbotapi ularni Message ga o'qimaydi,
+// shuning uchun update lar qo'lda olinadi va thread ID lar shu yerda saqlanadi
+const maxRememberedThreads = 5000
+
+var (
+	messageThreadsMu sync.Mutex
+	messageThreads   = make(map[string]int)
+	threadOrder      []string
+)
+
+// Update ichidan faqat thread ma'lumotini o'qish uchun
+type threadedUpdate struct {
+	Message       *threadedMessage `json:"message"`
+	EditedMessage *threadedMessage `json:"edited_message"`
+}
+
+type threadedMessage struct {
+	MessageID       int `json:"message_id"`
+	MessageThreadID int `json:"message_thread_id"`
+	Chat            struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+func rememberThread(m *threadedMessage) {
+	if m == nil || m.MessageThreadID == 0 {
+		return
+	}
+	key := listSessionKey(m.Chat.ID, m.MessageID)
+
+	messageThreadsMu.Lock()
+	defer messageThreadsMu.Unlock()
+
+	if _, exists := messageThreads[key]; !exists {
+		threadOrder = append(threadOrder, key)
+	}
+	messageThreads[key] = m.MessageThreadID
+	for len(threadOrder) > maxRememberedThreads {
+		delete(messageThreads, threadOrder[0])
+		threadOrder = threadOrder[1:]
+	}
+}
+
+// Xabar yozilgan forum thread ID si (0 - General yoki oddiy guruh)
+func messageThreadID(message *tgbotapi.Message) int {
+	messageThreadsMu.Lock()
+	defer messageThreadsMu.Unlock()
+
+	return messageThreads[listSessionKey(message.Chat.ID, message.MessageID)]
+}
+
+// bot.GetUpdatesChan o'rniga: getUpdates javobidan thread ID larni ham o'qiydi
+func getUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	ch := make(chan tgbotapi.Update, bot.Buffer)
+
+	go func() {
+		for {
+			params := make(tgbotapi.Params)
+			params.AddNonZero("offset", config.Offset)
+			params.AddNonZero("limit", config.Limit)
+			params.AddNonZero("timeout", config.Timeout)
+			if err := params.AddInterface("allowed_updates", config.AllowedUpdates); err != nil {
+				log.Printf("❌ getUpdates parametrlari noto'g'ri: %v", err)
+			}
+
+			resp, err := bot.MakeRequest("getUpdates", params)
+			var updates []tgbotapi.Update
+			var threaded []threadedUpdate
+			if err == nil {
+				err = json.Unmarshal(resp.Result, &updates)
+			}
+			if err == nil {
+				err = json.Unmarshal(resp.Result, &threaded)
+			}
+			if err != nil {
+				log.Printf("❌ Update larni olib bo'lmadi, 3 soniyadan keyin qayta urinish: %v", err)
+				time.Sleep(3 * time.Second)
+				continue
+			}
+
+			for i, update := range updates {
+				if update.UpdateID < config.Offset {
+					continue
+				}
+				config.Offset = update.UpdateID + 1
+				rememberThread(threaded[i].Message)
+				rememberThread(threaded[i].EditedMessage)
+				ch <- update
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Xabar nusxasi (copyMessage): matn, rasm, hujjat, ovozli xabar "forward"
+// belgisisiz ko'chiriladi. tgbotapi dagi CopyMessageConfig thread ID ni bilmaydi
+type CopyMessageConfig struct {
+	ChatID              int64
+	MessageThreadID     int
+	FromChatID          int64
+	MessageID           int
+	ReplyToMessageID    int
+	Caption             string // Bo'sh bo'lsa asl izoh saqlanadi
+	ParseMode           string
+	DisableNotification bool
+}
+
+// Xabarni ko'chirish. Yangi xabar ID si qaytariladi
+func copyMessage(c CopyMessageConfig) (int, error) {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", c.ChatID)
+	params.AddNonZero("message_thread_id", c.MessageThreadID)
+	params.AddNonZero64("from_chat_id", c.FromChatID)
+	params.AddNonZero("message_id", c.MessageID)
+	params.AddNonZero("reply_to_message_id", c.ReplyToMessageID)
+	params.AddNonEmpty("caption", c.Caption)
+	params.AddNonEmpty("parse_mode", c.ParseMode)
+	params.AddBool("disable_notification", c.DisableNotification)
+	if c.ReplyToMessageID != 0 {
+		params.AddBool("allow_sending_without_reply", true)
+	}
+
+	resp, err := bot.MakeRequest("copyMessage", params)
+	if err != nil {
+		return 0, err
+	}
+	var copied tgbotapi.MessageID
+	err = json.Unmarshal(resp.Result, &copied)
+	return copied.MessageID, err
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type TopicsData struct {
+	Topics []TopicInfo `json:"topics"`
+}
+
+// Davlat -> admin guruhidagi topic marshrutlash jadvali. Jadval topics.json
+// faylida saqlanadi, /topic buyruqlari va qayta yuklash orqali o'zgaradi
+type TopicRegistry struct {
+	mu      sync.RWMutex
+	path    string
+	topics  []TopicInfo
+	aliases *countryIndex // Jadval o'zgarganda qayta quriladi
+}
+
+// Global topic jadvali
+var topics *TopicRegistry
+
+// Fayl hali yo'q bo'lganda yoziladigan boshlang'ich topiclar
+func defaultTopics(adminChatID int64) []TopicInfo {
+	return []TopicInfo{
+		{ChatID: adminChatID, MessageThreadID: 2, Text: "UK"},
+		{ChatID: adminChatID, MessageThreadID: 4, Text: "Schengen"},
+		{ChatID: adminChatID, MessageThreadID: 6, Text: "Australia"},
+		{ChatID: adminChatID, MessageThreadID: 8, Text: "Japan"},
+		{ChatID: adminChatID, MessageThreadID: 10, Text: "Peru"},
+		{ChatID: adminChatID, MessageThreadID: 12, Text: "India"},
+		{ChatID: adminChatID, MessageThreadID: 14, Text: "Argentina"},
+		{ChatID: adminChatID, MessageThreadID: 16, Text: "Uganda"},
+		{ChatID: adminChatID, MessageThreadID: 18, Text: "Kuwait"},
+		{ChatID: adminChatID, MessageThreadID: 20, Text: "Pakistan"},
+		{ChatID: adminChatID, MessageThreadID: 22, Text: "Albania"},
+		{ChatID: adminChatID, MessageThreadID: 24, Text: "Hong Kong"},
+		{ChatID: adminChatID, MessageThreadID: 26, Text: "Ireland"},
+		{ChatID: adminChatID, MessageThreadID: 28, Text: "Cyprus"},
+		{ChatID: adminChatID, MessageThreadID: 30, Text: "Zimbabwe"},
+	}
+}
+
+// Topic jadvalini fayldan yuklash. Fayl mavjud bo'lmasa boshlang'ich
+// topiclar bilan yaratiladi
+func loadTopics(path string, adminChatID int64) (*TopicRegistry, error) {
+	r := &TopicRegistry{path: path}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.topics = defaultTopics(adminChatID)
+		r.aliases = buildCountryIndex(r.topics)
+		if err := r.save(); err != nil {
+			return nil, err
+		}
+		log.Printf("📁 Topiclar fayli yaratildi: %s", path)
+	} else if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ %d ta topic yuklandi", len(r.topics))
+	return r, nil
+}
+
+// Faylni qayta o'qish (bot qayta ishga tushirilmaydi)
+func (r *TopicRegistry) Reload() error {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("topiclar faylini o'qib bo'lmadi: %w", err)
+	}
+
+	var topicsData TopicsData
+	if err := json.Unmarshal(data, &topicsData); err != nil {
+		return fmt.Errorf("topiclar fayli noto'g'ri (%s): %w", r.path, err)
+	}
+
+	for _, topic := range topicsData.Topics {
+		if strings.TrimSpace(topic.Text) == "" || topic.ChatID == 0 || topic.MessageThreadID <= 0 {
+			return fmt.Errorf("topiclar faylida noto'g'ri yozuv: %+v", topic)
+		}
+	}
+
+	aliases := buildCountryIndex(topicsData.Topics)
+
+	r.mu.Lock()
+	r.topics = topicsData.Topics
+	r.aliases = aliases
+	r.mu.Unlock()
+	return nil
+}
+
+// Jadvalni faylga yozish (lock ushlab turilgan yoki hali ulashilmagan holda)
+func (r *TopicRegistry) save() error {
+	data, err := json.MarshalIndent(TopicsData{Topics: r.topics}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data, 0644)
+}
+
+// Barcha topiclar nusxasi
+func (r *TopicRegistry) List() []TopicInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]TopicInfo(nil), r.topics...)
+}
+
+// Davlat nomi bo'yicha topic topish
+func (r *TopicRegistry) Find(country string) (TopicInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, topic := range r.topics {
+		if strings.EqualFold(topic.Text, country) {
+			return topic, true
+		}
+	}
+	return TopicInfo{}, false
+}
+
+// Davlatlarni aniqlash indeksi. Jadval yuklanganda va o'zgarganda quriladi;
+// hali qurilmagan bo'lsa (masalan jadval qo'lda yaratilgan) shu yerda quriladi
+func (r *TopicRegistry) countryIndex() *countryIndex {
+	r.mu.RLock()
+	idx := r.aliases
+	r.mu.RUnlock()
+	if idx != nil {
+		return idx
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aliases == nil {
+		r.aliases = buildCountryIndex(r.topics)
+	}
+	return r.aliases
+}
+
+// Topic qo'shish yoki mavjud davlat topicini yangilash
+func (r *TopicRegistry) Put(topic TopicInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replaced := false
+	for i := range r.topics {
+		if strings.EqualFold(r.topics[i].Text, topic.Text) {
+			r.topics[i] = topic
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.topics = append(r.topics, topic)
+	}
+	r.aliases = buildCountryIndex(r.topics)
+	return r.save()
+}
+
+// Davlat topicini o'chirish
+func (r *TopicRegistry) Remove(country string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.topics {
+		if strings.EqualFold(r.topics[i].Text, country) {
+			r.topics = append(r.topics[:i], r.topics[i+1:]...)
+			r.aliases = buildCountryIndex(r.topics)
+			return true, r.save()
+		}
+	}
+	return false, nil
+}
+
+// /topic buyrug'i: add, list, remove, reload
+func handleTopicCommand(message *tgbotapi.Message) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		replyText(message, topicUsage)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		list := topics.List()
+		if len(list) == 0 {
+			replyText(message, "📋 Topiclar jadvali bo'sh")
+			return
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Text < list[j].Text })
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📋 Topiclar (%d ta):\n", len(list))
+		for _, topic := range list {
+			fmt.Fprintf(&sb, "• %s → thread %d\n", topic.Text, topic.MessageThreadID)
+		}
+		replyText(message, sb.String())
+
+	case "add":
+		// /topic add Hong Kong 24 - oxirgi argument thread ID.
+		// /topic add Canada - thread ID siz: topic admin forumida yaratiladi
+		if len(args) < 2 {
+			replyText(message, "❗ Foydalanish: /topic add <davlat> [thread_id]")
+			return
+		}
+		threadID, err := strconv.Atoi(args[len(args)-1])
+		if err != nil || len(args) < 3 {
+			country := strings.Join(args[1:], " ")
+			if existing, ok := topics.Find(country); ok {
+				replyText(message, fmt.Sprintf("ℹ️ %s topici allaqachon bor → thread %d", existing.Text, existing.MessageThreadID))
+				return
+			}
+			topic, err := ensureCountryTopic(country)
+			if err != nil {
+				log.Printf("❌ %v", err)
+				replyText(message, "❌ Topic yaratib bo'lmadi (botda \"Manage topics\" huquqi bormi?)")
+				return
+			}
+			replyText(message, fmt.Sprintf("🆕 %s → thread %d (yangi topic yaratildi)", topic.Text, topic.MessageThreadID))
+			return
+		}
+		if threadID <= 0 {
+			replyText(message, "❗ Thread ID musbat son bo'lishi kerak")
+			return
+		}
+		country := strings.Join(args[1:len(args)-1], " ")
+		topic := TopicInfo{ChatID: cfg.AdminChatID, MessageThreadID: threadID, Text: country}
+		if err := topics.Put(topic); err != nil {
+			log.Printf("❌ Topicni saqlashda xato: %v", err)
+			replyText(message, "❌ Topicni saqlab bo'lmadi")
+			return
+		}
+		log.Printf("➕ Topic qo'shildi: %s -> Thread: %d", country, threadID)
+		replyText(message, fmt.Sprintf("✅ %s → thread %d", country, threadID))
+
+	case "remove":
+		if len(args) < 2 {
+			replyText(message, "❗ Foydalanish: /topic remove <davlat>")
+			return
+		}
+		country := strings.Join(args[1:], " ")
+		removed, err := topics.Remove(country)
+		if err != nil {
+			log.Printf("❌ Topicni o'chirishda xato: %v", err)
+			replyText(message, "❌ Topicni o'chirib bo'lmadi")
+			return
+		}
+		if !removed {
+			replyText(message, fmt.Sprintf("❓ %s uchun topic topilmadi", country))
+			return
+		}
+		log.Printf("➖ Topic o'chirildi: %s", country)
+		replyText(message, fmt.Sprintf("🗑️ %s topici o'chirildi", country))
+
+	case "reload":
+		if err := topics.Reload(); err != nil {
+			log.Printf("❌ Topiclarni qayta yuklashda xato: %v", err)
+			replyText(message, "❌ "+err.Error())
+			return
+		}
+		replyText(message, fmt.Sprintf("🔄 %d ta topic qayta yuklandi", len(topics.List())))
+
+	default:
+		replyText(message, topicUsage)
+	}
+}
+
+const topicUsage = `📋 Topic buyruqlari:
+/topic list - barcha topiclar
+/topic add <davlat> - admin forumida yangi topic yaratish
+/topic add <davlat> <thread_id> - mavjud topicni biriktirish yoki yangilash
+/topic remove <davlat> - topicni o'chirish
+/topic reload - topics.json ni qayta o'qish`
+
+// Yangi topic yaratishni ketma-ket bajarish - bitta davlat uchun ikki marta yaratilmasin
+var topicCreateMu sync.Mutex
+
+// Aniqlash mumkin bo'lgan davlatlar: topic jadvali va visa katalogi.
+// "Aniqlanmadi" topici davlat hisoblanmaydi
+func knownCountries(list []TopicInfo) []string {
+	seen := make(map[string]bool)
+	var countries []string
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if seen[key] || strings.EqualFold(name, cfg.UnassignedTopic) {
+			return
+		}
+		seen[key] = true
+		countries = append(countries, name)
+	}
+
+	for _, topic := range list {
+		add(topic.Text)
+	}
+	catalog := make([]string, 0, len(visaData))
+	for name := range visaData {
+		catalog = append(catalog, name)
+	}
+	sort.Strings(catalog)
+	for _, name := range catalog {
+		add(name)
+	}
+	return countries
+}
+
+// Davlat uchun topicni topish. Jadvalda bo'lmasa admin guruhida yangi
+// forum topic yaratiladi va uning thread ID si jadvalga yoziladi
+func ensureCountryTopic(country string) (TopicInfo, error) {
+	if topic := findTopicByCountry(country); topic != nil {
+		return *topic, nil
+	}
+
+	topicCreateMu.Lock()
+	defer topicCreateMu.Unlock()
+
+	// Lock kutilayotganda boshqa goroutine yaratgan bo'lishi mumkin
+	if topic := findTopicByCountry(country); topic != nil {
+		return *topic, nil
+	}
+
+	name := "❓ " + country
+	if info, ok := visaData[country]; ok && info.Flag != "" {
+		name = info.Flag + " " + country
+	}
+
+	created, err := createForumTopic(cfg.AdminChatID, name)
+	if err != nil {
+		return TopicInfo{}, fmt.Errorf("%s uchun topic yaratib bo'lmadi: %w", country, err)
+	}
+
+	topic := TopicInfo{ChatID: cfg.AdminChatID, MessageThreadID: created.MessageThreadID, Text: country}
+	if err := topics.Put(topic); err != nil {
+		// Topic Telegramda yaratildi - jadvalga yozilmasa ham shu safar ishlatamiz
+		log.Printf("❌ Yangi topicni jadvalga yozishda xato: %v", err)
+	}
+
+	log.Printf("🆕 Yangi topic yaratildi: %s -> Thread: %d", name, created.MessageThreadID)
+	return topic, nil
+}
@@ -1,5 +1,16 @@
 package main
 
+// Visa katalogidagi bitta davlat ma'lumoti
+type VisaInfo struct {
+	Flag           string
+	ServicePrice   string
+	VisaType       string
+	VisaFee        string
+	ProcessingTime string
+	Requirements   string
+	Details        string
+}
+
 // VisaData - Barcha davlatlar uchun visa ma'lumotlari
 var visaData = map[string]VisaInfo{
 	"Schengen": {